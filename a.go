@@ -0,0 +1,24 @@
+package probe
+
+type S struct{ X int }
+
+func next() *S { return nil }
+
+// closure assigned in an earlier closure
+func earlyClosure(p *S) {
+	go func() { p = nil }()
+	if p == nil {
+		return
+	}
+	go func() {
+		_ = p.X
+	}()
+}
+
+func unused1(p *S) {
+	if p == nil {
+		return
+	}
+	_ = p.X //nolint:nilguard // a
+	_ = p.X //nolint:nilguard // a
+}
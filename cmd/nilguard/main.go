@@ -1,11 +1,450 @@
-// Command nilguard runs the analyzer as a singlechecker CLI.
+// Command nilguard runs the nilguard analyzer over Go packages.
+//
+// Usage:
+//
+//	nilguard [flags] [packages]
+//	nilguard baseline write [flags] [packages]
+//	nilguard report -html DIR [flags] [packages]
+//	nilguard lsp [flags]
+//
+// Findings are printed as text, one per line, by default. Use -format=sarif
+// to emit a SARIF 2.1.0 log for code-scanning dashboards, or -format=json for
+// the JSON schema documented in internal/report (WriteJSON), which also lists
+// the findings suppressed by directives or the baseline with their
+// suppression state. In CI, -format=github prints GitHub Actions workflow
+// commands (::warning file=...,line=...::message) that annotate the changed
+// files, and -format=gitlab writes a GitLab Code Quality report for merge
+// requests. -format=checkstyle and -format=junit write XML reports for
+// Jenkins and other CI servers; the JUnit report has one test case per
+// package, which fails if the package has findings.
+//
+// The "report" subcommand writes DIR/index.html, a self-contained HTML
+// report for sharing: totals per package and per function, pointer uses
+// against guarded uses, the findings with source excerpts, and the
+// suppressed findings with their reasons.
+//
+// The "lsp" subcommand runs a language server on stdin and stdout for
+// editors without gopls analyzer settings. It publishes diagnostics for the
+// package of each file opened or saved, and offers the suggested fixes as
+// code actions; the other flags, such as -profile, configure the analysis.
+//
+// With -stats, nilguard prints guard coverage metrics instead of findings:
+// per package and function, the pointers used, their uses, and how many uses
+// are guarded, suppressed by a directive, or trusted without a nil check
+// (type assertions, and non-nil assignments under the standard and strict
+// profiles). They are printed as a table, or as JSON with -format=json.
+//
+// If a baseline file (.nilguard-baseline.json by default, see -baseline)
+// exists, findings recorded in it are suppressed; only new findings and
+// baseline entries that no longer match any finding are reported. The
+// "baseline write" subcommand records the current findings in that file.
+//
+// With -new-from-rev=REV (or -new-from-patch=FILE), only findings whose use
+// or enclosing function overlaps a line changed since REV (or in the unified
+// diff FILE) are reported. REV is resolved with the local git binary; no
+// network access is needed.
+//
+// With -explain=FILE:LINE, nilguard prints instead the reasoning behind its
+// verdicts for the functions enclosing that line: every pointer use, every
+// nil-check and whether it was accepted as a guard, and the resulting
+// verdict. The packages default to the one containing FILE. -trace prints the
+// same for every function.
+//
+// The -fix, -diff and -c flags behave as in singlechecker: -fix applies the
+// preferred suggested fix of every reported finding (a nil guard, or the
+// removal of an unused directive) to the source files, -fix -diff prints the
+// changes as unified diffs instead, and -c=N prints the source line of each
+// finding in text output with N lines of context. singlechecker's -json is
+// replaced by -format=json.
+//
+// As with singlechecker, the exit status is 3 when text (or github) output
+// contains findings (or stale baseline entries) and 1 when packages could
+// not be loaded.
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
 	"github.com/HMetcalfe/nilguard/internal/analyzer"
-	"golang.org/x/tools/go/analysis/singlechecker"
+	"github.com/HMetcalfe/nilguard/internal/baseline"
+	"github.com/HMetcalfe/nilguard/internal/diff"
+	"github.com/HMetcalfe/nilguard/internal/driver"
+	"github.com/HMetcalfe/nilguard/internal/lsp"
+	"github.com/HMetcalfe/nilguard/internal/report"
+	"golang.org/x/tools/go/analysis"
 )
 
 func main() {
-	singlechecker.Main(analyzer.Analyzer)
+	log.SetFlags(0)
+	log.SetPrefix("nilguard: ")
+
+	args := os.Args[1:]
+	writeBaseline, htmlReport, lspMode := false, false, false
+	switch {
+	case len(args) > 0 && args[0] == "baseline":
+		if len(args) < 2 || args[1] != "write" {
+			log.Fatal("usage: nilguard baseline write [flags] [packages]")
+		}
+		writeBaseline, args = true, args[2:]
+	case len(args) > 0 && args[0] == "report":
+		htmlReport, args = true, args[1:]
+	case len(args) > 0 && args[0] == "lsp":
+		lspMode, args = true, args[1:]
+	}
+
+	a := analyzer.Analyzer
+
+	format := flag.String("format", "text", "output format: text, sarif, json, github, gitlab, checkstyle or junit")
+	tests := flag.Bool("test", true, "indicates whether test files should be analyzed, too")
+	baselinePath := flag.String("baseline", baseline.DefaultFile, "baseline file of accepted findings (empty to disable)")
+	newFromRev := flag.String("new-from-rev", "", "only report findings in code changed since this git revision")
+	newFromPatch := flag.String("new-from-patch", "", "only report findings in code changed by this unified diff file")
+	htmlDir := flag.String("html", "", "with the report subcommand, write an HTML report to `DIR`")
+	stats := flag.Bool("stats", false, "print guard coverage metrics per package and function instead of findings (as a table, or JSON with -format=json)")
+	explain := flag.String("explain", "", "explain the verdicts for the functions enclosing `FILE:LINE` instead of reporting findings")
+	fix := flag.Bool("fix", false, "apply the preferred suggested fix of every finding")
+	diffOnly := flag.Bool("diff", false, "with -fix, print the changes as unified diffs instead of applying them")
+	contextLines := flag.Int("c", -1, "display the offending line of each finding with `N` lines of context (text output)")
+
+	// Expose the analyzer's own flags (e.g. -exclude-tests) unprefixed, as
+	// singlechecker does.
+	a.Flags.VisitAll(func(f *flag.Flag) {
+		flag.Var(f.Value, f.Name, f.Usage)
+	})
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s: %s\n\nUsage:\n  %s [-flag] [package]\n  %s baseline write [-flag] [package]\n  %s report -html DIR [-flag] [package]\n  %s lsp [-flag]\n\nFlags:\n",
+			a.Name, a.Doc, a.Name, a.Name, a.Name, a.Name)
+		flag.PrintDefaults()
+	}
+	if err := flag.CommandLine.Parse(args); err != nil {
+		os.Exit(2)
+	}
+
+	if lspMode {
+		if flag.NArg() > 0 {
+			log.Fatal("usage: nilguard lsp [flags]")
+		}
+		if err := lsp.NewServer(a, driver.Config{Tests: *tests}).Serve(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *explain != "" || a.Flags.Lookup("trace").Value.String() == "true" {
+		if writeBaseline || htmlReport {
+			log.Fatal("-explain and -trace are not supported by subcommands")
+		}
+		runExplain(a, *explain, flag.Args(), driver.Config{Tests: *tests})
+		return
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *stats {
+		if writeBaseline || htmlReport {
+			log.Fatal("-stats is not supported by subcommands")
+		}
+		runStats(a, *format, flag.Args(), driver.Config{Tests: *tests})
+		return
+	}
+	if htmlReport != (*htmlDir != "") {
+		log.Fatal("usage: nilguard report -html DIR [flags] [packages]")
+	}
+	if *newFromRev != "" && *newFromPatch != "" {
+		log.Fatal("-new-from-rev and -new-from-patch are mutually exclusive")
+	}
+	if *diffOnly && !*fix {
+		log.Fatal("-diff requires -fix")
+	}
+	if *fix && (writeBaseline || htmlReport) {
+		log.Fatal("-fix is not supported by subcommands")
+	}
+
+	// JSON output and the HTML report also list suppressed findings, with
+	// their state.
+	withSuppressed := (*format == "json" || htmlReport) && !writeBaseline
+	res, err := driver.Collect(a, flag.Args(), driver.Config{Tests: *tests, Suppressed: withSuppressed})
+	if err != nil {
+		log.Fatal(err)
+	}
+	findings, pkgs := res.Findings, res.Packages
+	var suppressed []driver.Finding
+	if withSuppressed {
+		active := findings[:0:0]
+		for _, f := range findings {
+			if f.Suppression != nil {
+				suppressed = append(suppressed, f)
+			} else {
+				active = append(active, f)
+			}
+		}
+		findings = active
+	}
+
+	if writeBaseline {
+		if *baselinePath == "" {
+			log.Fatal("baseline write: -baseline must not be empty")
+		}
+		b := baseline.New(findings)
+		if err := b.Write(*baselinePath); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("wrote %d baseline entries (%d findings) to %s", len(b.Entries), len(findings), *baselinePath)
+		return
+	}
+
+	var stale []baseline.Entry
+	if *baselinePath != "" {
+		b, err := baseline.Read(*baselinePath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// No baseline: report everything.
+		case err != nil:
+			log.Fatal(err)
+		default:
+			var accepted []driver.Finding
+			findings, accepted, stale = b.Match(findings)
+			if withSuppressed {
+				for _, f := range accepted {
+					f.Suppression = &driver.Suppression{Kind: driver.SuppressedByBaseline}
+					suppressed = append(suppressed, f)
+				}
+			}
+		}
+	}
+
+	if *newFromRev != "" || *newFromPatch != "" {
+		changes, err := loadChanges(*newFromRev, *newFromPatch)
+		if err != nil {
+			log.Fatal(err)
+		}
+		findings = changes.Filter(findings)
+		suppressed = changes.Filter(suppressed)
+	}
+
+	if *fix {
+		if err := applyFixes(findings, *diffOnly); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if htmlReport {
+		all := append(findings, suppressed...)
+		driver.Sort(all)
+		if err := writeHTML(*htmlDir, all, res.Stats, pkgs, root); err != nil {
+			log.Fatal(err)
+		}
+		for _, e := range stale {
+			log.Printf("%s: stale baseline entry: %s", *baselinePath, e)
+		}
+		return
+	}
+
+	switch *format {
+	case "text":
+		err = report.WriteTextContext(os.Stdout, findings, *contextLines)
+	case "sarif":
+		err = report.WriteSARIF(os.Stdout, a, findings, root)
+	case "json":
+		all := append(findings, suppressed...)
+		driver.Sort(all)
+		err = report.WriteJSON(os.Stdout, all, root)
+	case "github":
+		err = report.WriteGitHub(os.Stdout, findings, root)
+	case "gitlab":
+		err = report.WriteGitLab(os.Stdout, findings, root)
+	case "checkstyle":
+		err = report.WriteCheckstyle(os.Stdout, findings, root)
+	case "junit":
+		err = report.WriteJUnit(os.Stdout, findings, pkgs, root)
+	default:
+		log.Fatalf("unknown -format %q (want text, sarif, json, github, gitlab, checkstyle or junit)", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, e := range stale {
+		log.Printf("%s: stale baseline entry: %s", *baselinePath, e)
+	}
+	if (*format == "text" || *format == "github") && (len(findings) > 0 || len(stale) > 0) {
+		os.Exit(3)
+	}
+}
+
+// applyFixes applies the preferred fix of every finding to the source files
+// or, if diffOnly is set, prints the changes as unified diffs.
+func applyFixes(findings []driver.Finding, diffOnly bool) error {
+	edits, skipped := driver.PreferredEdits(findings)
+	for _, name := range slices.Sorted(maps.Keys(edits)) {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if diffOnly {
+			if err := report.WriteDiff(os.Stdout, name, src, edits[name]); err != nil {
+				return err
+			}
+			continue
+		}
+		out, err := driver.Apply(src, edits[name])
+		if err != nil {
+			return err
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, out, fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if skipped > 0 {
+		log.Printf("skipped %d fixes that conflict with others; run again to apply them", skipped)
+	}
+	return nil
+}
+
+// runStats prints the guard coverage metrics of the packages matching
+// patterns in format, which must be text or json.
+func runStats(a *analysis.Analyzer, format string, patterns []string, cfg driver.Config) {
+	write := report.WriteStats
+	switch format {
+	case "text":
+	case "json":
+		write = report.WriteStatsJSON
+	default:
+		log.Fatalf("-stats does not support -format %q (want text or json)", format)
+	}
+	res, err := driver.Collect(a, patterns, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := write(os.Stdout, res.Stats, res.Packages, root); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeHTML writes the HTML report to dir/index.html, creating dir if needed.
+func writeHTML(dir string, findings []driver.Finding, stats []driver.FuncStats, pkgs []string, root string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "index.html")
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteHTML(f, findings, stats, pkgs, root); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("wrote %s", path)
+	return nil
+}
+
+// runExplain prints the traces of the functions enclosing loc (FILE:LINE), or
+// of every function if loc is empty, for the packages matching patterns. If
+// there are no patterns, the package containing FILE is used.
+func runExplain(a *analysis.Analyzer, loc string, patterns []string, cfg driver.Config) {
+	if err := a.Flags.Set("trace", "true"); err != nil {
+		log.Fatal(err)
+	}
+
+	var file string
+	var line int
+	if loc != "" {
+		i := strings.LastIndex(loc, ":")
+		n, err := strconv.Atoi(loc[i+1:])
+		if i < 0 || err != nil || n <= 0 {
+			log.Fatalf("invalid -explain %q (want FILE:LINE)", loc)
+		}
+		abs, err := filepath.Abs(loc[:i])
+		if err != nil {
+			log.Fatal(err)
+		}
+		file, line = abs, n
+		if len(patterns) == 0 {
+			patterns = []string{"file=" + file}
+		}
+	}
+	if len(patterns) == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	traces, err := driver.Traces(a, patterns, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if file != "" {
+		var enclosing []driver.Trace
+		for _, t := range traces {
+			if sameFile(t.FuncStart.Filename, file) && t.FuncStart.Line <= line && line <= t.FuncEnd.Line {
+				enclosing = append(enclosing, t)
+			}
+		}
+		if len(enclosing) == 0 {
+			log.Fatalf("no analyzed function encloses %s", loc)
+		}
+		traces = enclosing
+	}
+	if err := report.WriteTrace(os.Stdout, traces); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// sameFile reports whether the paths a and b name the same file.
+func sameFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	return err == nil && os.SameFile(fa, fb)
+}
+
+// loadChanges returns the changed lines for -new-from-rev or -new-from-patch.
+func loadChanges(rev, patch string) (diff.Changes, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if rev != "" {
+		return diff.FromGit(wd, rev)
+	}
+	f, err := os.Open(patch)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return diff.Parse(f, wd)
 }
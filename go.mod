@@ -2,7 +2,10 @@ module github.com/HMetcalfe/nilguard
 
 go 1.25
 
-require golang.org/x/tools v0.33.0
+require (
+	github.com/golangci/plugin-module-register v0.1.2
+	golang.org/x/tools v0.33.0
+)
 
 require (
 	golang.org/x/mod v0.24.0 // indirect
@@ -1,9 +1,14 @@
 package analyzer
 
 import (
+	"flag"
+	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"reflect"
+	"slices"
+	"sort"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
@@ -21,31 +26,153 @@ import (
 //	functions and do not share state with their enclosing functions.
 //
 // The Analyzer does not perform any I/O beyond reporting diagnostics through
-// the provided analysis.Pass.
-var Analyzer = &analysis.Analyzer{
-	Name: "nilguard",
-	Doc:  "flags pointers used in a function without any nil check in that function (v1 policy)",
-	Requires: []*analysis.Analyzer{
-		inspect.Analyzer,
-	},
-	Run: run,
+// the provided analysis.Pass. Its result is a *Result describing every
+// reported diagnostic together with its enclosing function and pointer.
+//
+// Analyzer uses the default Config; its options can be changed through its
+// flags. Use New to create independently configured instances.
+var Analyzer = New(Config{})
+
+// Config holds the options of a nilguard Analyzer. The zero Config is the
+// default v1 policy.
+type Config struct {
+	// ExcludeTests skips functions in _test.go files.
+	ExcludeTests bool
+
+	// ClosureGuards lets function literals inherit guards for captured
+	// pointers from their enclosing functions (see inheritedGuards).
+	ClosureGuards bool
+
+	// OnlyFiles, if non-empty, is a regular expression; only functions in
+	// files whose names match it are analyzed. It mirrors nogo's only_files.
+	OnlyFiles string
+
+	// ExcludeFiles, if non-empty, is a regular expression; functions in files
+	// whose names match it are skipped. It mirrors nogo's exclude_files.
+	ExcludeFiles string
+
+	// RequireReason reports suppression directives that do not explain
+	// themselves with a trailing "// reason".
+	RequireReason bool
+
+	// IncludeGenerated analyzes files carrying a "// Code generated ... DO
+	// NOT EDIT." header, which are skipped by default.
+	IncludeGenerated bool
+
+	// Trace records, in Result.Traces, every use, guard and verdict
+	// considered for each function, to explain the diagnostics.
+	Trace bool
+
+	// Report selects how unguarded pointers are reported: ReportFirst (the
+	// default, also used when empty), ReportAll or ReportSummary.
+	Report string
+
+	// Profile selects the policy: ProfileLenient (the default, also used
+	// when empty), ProfileStandard or ProfileStrict. It also determines the
+	// severity of each Finding.
+	Profile string
 }
 
-var excludeTests bool
+// Values of Config.Report.
+const (
+	// ReportFirst reports each unguarded pointer once, at its first use.
+	ReportFirst = "first"
+
+	// ReportAll reports every use of an unguarded pointer.
+	ReportAll = "all"
+
+	// ReportSummary reports each unguarded pointer once, at its first use,
+	// with related information listing its other uses.
+	ReportSummary = "summary"
+)
+
+// New returns a nilguard Analyzer that applies cfg. The returned Analyzer
+// is independent of Analyzer: it has its own flag set, initialized from cfg,
+// so several differently configured instances can coexist in one process
+// (for example in a nogo binary).
+func New(cfg Config) *analysis.Analyzer {
+	c := &cfg
+	a := &analysis.Analyzer{
+		Name: "nilguard",
+		Doc:  "flags pointers used in a function without any nil check in that function (v1 policy)",
+		Requires: []*analysis.Analyzer{
+			inspect.Analyzer,
+		},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			return run(pass, *c)
+		},
+		ResultType: reflect.TypeOf((*Result)(nil)),
+		FactTypes:  []analysis.Fact{new(returnsNonNil)},
+	}
+	bindFlags(&a.Flags, c)
+	return a
+}
 
-func init() {
-	Analyzer.Flags.BoolVar(&excludeTests, "exclude-tests", false, "exclude _test.go files from analysis")
+// bindFlags defines the nilguard flags on fs, bound to the fields of cfg and
+// defaulting to their current values.
+func bindFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.BoolVar(&cfg.ExcludeTests, "exclude-tests", cfg.ExcludeTests, "exclude _test.go files from analysis")
+	fs.BoolVar(&cfg.ClosureGuards, "closure-guards", cfg.ClosureGuards, "treat captured pointers guarded in the enclosing function as checked inside function literals")
+	fs.StringVar(&cfg.OnlyFiles, "only-files", cfg.OnlyFiles, "only analyze files whose names match this regular expression (nogo only_files)")
+	fs.StringVar(&cfg.ExcludeFiles, "exclude-files", cfg.ExcludeFiles, "skip files whose names match this regular expression (nogo exclude_files)")
+	fs.BoolVar(&cfg.IncludeGenerated, "include-generated", cfg.IncludeGenerated, "analyze generated files (those with a \"Code generated ... DO NOT EDIT.\" header)")
+	fs.BoolVar(&cfg.Trace, "trace", cfg.Trace, "record why each pointer was considered checked or unchecked (see nilguard -explain)")
+	fs.StringVar(&cfg.Report, "report", cfg.Report, "how to report unguarded pointers: first (one diagnostic at the first use), all (every use), or summary (first use, listing the others)")
+	fs.StringVar(&cfg.Profile, "profile", cfg.Profile, "policy profile: lenient (any nil check in the function), standard (each use dominated by a guard), or strict (standard, plus field and element loads and no trust in type assertions)")
+	fs.BoolVar(&cfg.RequireReason, "require-reason", cfg.RequireReason, "report suppression directives without a \"// reason\"")
 }
 
-// run is the main entrypoint invoked by the analysis framework. It retrieves
-// the precomputed inspector and applies our per-function analysis to each
-// function declaration and function literal in the package.
-func run(pass *analysis.Pass) (interface{}, error) {
+// run is the main entrypoint invoked by the analysis framework. It
+// retrieves the precomputed inspector and applies our per-function analysis
+// to each function declaration and function literal in the package, as
+// configured by cfg.
+func run(pass *analysis.Pass, cfg Config) (interface{}, error) {
+	skipFile, err := fileFilter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Report {
+	case "", ReportFirst, ReportAll, ReportSummary:
+	default:
+		return nil, fmt.Errorf("invalid -report %q (want first, all or summary)", cfg.Report)
+	}
+	if err := checkProfile(cfg); err != nil {
+		return nil, err
+	}
+
 	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
 
-	// Precompute an index of lines that have a nolint directive for nilguard.
-	noLintIndex := buildNoLintIndex(pass)
-	fileIndex := buildFileIndex(pass)
+	// Record which functions return non-nil pointers, for repair guards here
+	// and in importing packages.
+	exportNonNilFacts(pass)
+
+	st := &passState{
+		pass: pass,
+		cfg:  cfg,
+		// Precompute the suppression directives and the regions they cover.
+		suppressions: buildSuppressions(pass),
+		fileIndex:    buildFileIndex(pass),
+		skipped:      make(map[*token.File]bool),
+		result:       new(Result),
+	}
+
+	// Decide up front which files to skip, so that no time is spent on
+	// large generated files.
+	for _, f := range pass.Files {
+		tf := pass.Fset.File(f.Pos())
+		switch {
+		case cfg.ExcludeTests && isTestFile(pass.Fset, f.Pos()),
+			!cfg.IncludeGenerated && ast.IsGenerated(f),
+			skipFile(tf):
+			st.skipped[tf] = true
+		}
+	}
+
+	// names records the display name of every function visited so far, and
+	// litCounts the number of function literals seen directly inside each
+	// function, so that literals can be named F$1, F$2, ... like go/ssa does.
+	names := make(map[ast.Node]string)
+	litCounts := make(map[ast.Node]int)
 
 	// We care about function declarations and function literals. Both are
 	// treated the same from the perspective of our rule: each function body
@@ -55,29 +182,128 @@ func run(pass *analysis.Pass) (interface{}, error) {
 		(*ast.FuncLit)(nil),
 	}
 
-	ins.Preorder(nodeFilter, func(n ast.Node) {
-		if excludeTests && isTestFile(pass.Fset, n.Pos()) {
-			return
+	ins.WithStack(nodeFilter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			names[n] = funcDeclName(fn)
+		case *ast.FuncLit:
+			parent := enclosingFunc(stack[:len(stack)-1])
+			litCounts[parent]++
+			prefix := "init"
+			if parent != nil {
+				prefix = names[parent]
+			}
+			names[n] = fmt.Sprintf("%s$%d", prefix, litCounts[parent])
+		}
+
+		if st.skipped[pass.Fset.File(n.Pos())] {
+			return true
 		}
 		var body *ast.BlockStmt
 
+		// inherited holds captured pointers that are already guarded by an
+		// enclosing function. It is only populated for function literals when
+		// -closure-guards is enabled.
+		var inherited map[types.Object]bool
+
 		switch fn := n.(type) {
 		case *ast.FuncDecl:
 			// Methods and plain functions both appear as FuncDecl. If there is
 			// no body (e.g. an external declaration), there is nothing to do.
 			if fn.Body == nil {
-				return
+				return true
 			}
 			body = fn.Body
 
 		case *ast.FuncLit:
 			body = fn.Body
+			if cfg.ClosureGuards {
+				inherited = inheritedGuards(pass.TypesInfo, fn, stack)
+			}
 		}
 
-		checkFunc(pass, body, noLintIndex, fileIndex)
+		fc := funcContext{name: names[n], node: n}
+		checkFunc(st, fc, body, inherited)
+		checkNilAssignments(st, fc, body)
+		return true
+	})
+
+	checkSuppressions(st, cfg.RequireReason)
+	checkAnnotations(st)
+
+	for _, fs := range [][]Finding{st.result.Findings, st.result.Suppressed} {
+		sort.Slice(fs, func(i, j int) bool {
+			return fs[i].Diagnostic.Pos < fs[j].Diagnostic.Pos
+		})
+	}
+	sort.Slice(st.result.Stats, func(i, j int) bool {
+		return st.result.Stats[i].FuncPos < st.result.Stats[j].FuncPos
 	})
+	return st.result, nil
+}
+
+// funcContext identifies the function whose body is being checked.
+type funcContext struct {
+	// name is the display name recorded as Finding.Func.
+	name string
+
+	// node is the *ast.FuncDecl or *ast.FuncLit.
+	node ast.Node
+}
+
+// passState holds the per-package state shared by the function-level checks.
+type passState struct {
+	pass         *analysis.Pass
+	cfg          Config
+	suppressions []*suppression
+	fileIndex    map[string]bool
+
+	// skipped holds the files excluded from analysis by the Config.
+	skipped map[*token.File]bool
+
+	result *Result
+}
+
+// report emits d unless it lies outside the current package's files or is
+// suppressed by a //nolint:nilguard or //nilguard:ignore directive, and records it as a Finding
+// for pointer within function fn. Suppressed diagnostics are recorded in
+// Result.Suppressed instead.
+func (st *passState) report(fn funcContext, pointer string, d analysis.Diagnostic) {
+	// Skip diagnostics for files outside the current package's file set.
+	if !isFileInPackage(st.pass.Fset, st.fileIndex, d.Pos) {
+		return
+	}
+
+	f := Finding{
+		Diagnostic: d,
+		Severity:   severity(st.cfg.profile(), d.Category),
+		Func:       fn.name,
+		FuncPos:    fn.node.Pos(),
+		FuncEnd:    fn.node.End(),
+		Pointer:    pointer,
+	}
+	use := d.Pos
+	if d.Category == RuleNearMiss && len(d.Related) > 0 {
+		use = d.Related[0].Pos
+	}
+	var typ types.Type
+	if f.UseKind, typ = describeUse(st.pass, use, pointer); typ != nil {
+		f.PointerType = types.TypeString(typ, types.RelativeTo(st.pass.Pkg))
+	}
+
+	// Respect //nolint:nilguard and //nilguard:ignore directives.
+	if s := st.suppressed(d.Pos, pointer); s != nil {
+		f.Suppression = &Suppression{Directive: s.directive.String(), Reason: s.reason}
+		st.result.Suppressed = append(st.result.Suppressed, f)
+		return
+	}
 
-	return nil, nil
+	st.pass.Report(d)
+	st.result.Findings = append(st.result.Findings, f)
 }
 
 // checkFunc performs the per-function analysis for a single function body.
@@ -90,17 +316,58 @@ func run(pass *analysis.Pass) (interface{}, error) {
 // functions by the outer run() traversal, and their checks/uses do not
 // affect the enclosing function.
 //
+// inherited lists pointers that are considered nil-checked on entry to the
+// body (see inheritedGuards). It is nil unless closure guard inheritance is
+// enabled. Parameters annotated with //nilguard:nonnil (see nonNilParams)
+// are trusted on entry.
+//
 // At the end of the traversal, any pointer that was used at least once but
-// never nil-checked will result in a single diagnostic at its first use.
-func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, noLintIndex map[*token.File]map[int]bool, fileIndex map[string]bool) {
+// never nil-checked is reported as selected by Config.Report: by default,
+// with a single diagnostic at its first use. fn identifies the function for
+// the recorded Findings. With Config.Trace, every use, guard and verdict is
+// also recorded in a FuncTrace.
+func checkFunc(st *passState, fn funcContext, body *ast.BlockStmt, inherited map[types.Object]bool) {
+	pass := st.pass
+
 	// ptrs maps each pointer-typed identifier (by its *ast.Object) to its
 	// usage information within this function body.
 	ptrs := make(map[types.Object]*pointerUseInfo)
 
+	// tr collects the reasoning behind the verdicts when tracing is enabled.
+	var tr *FuncTrace
+	if st.cfg.Trace {
+		tr = &FuncTrace{Func: fn.name, FuncPos: fn.node.Pos(), FuncEnd: fn.node.End()}
+		defer func() { st.result.Traces = append(st.result.Traces, *tr) }()
+	}
+	trace := func(pos token.Pos, obj types.Object, kind, format string, args ...interface{}) {
+		if tr != nil {
+			tr.Events = append(tr.Events, TraceEvent{
+				Pos:     pos,
+				Pointer: obj.Name(),
+				Kind:    kind,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+	}
+
+	// Captured pointers guarded by an enclosing function start out checked.
+	for obj := range inherited {
+		ptrs[obj] = &pointerUseInfo{hasCheck: true}
+		trace(fn.node.Pos(), obj, TraceGuard, "accepted guard in the enclosing function (-closure-guards)")
+	}
+
+	// Parameters annotated with //nilguard:nonnil start out trusted.
+	annotated := nonNilParams(pass.TypesInfo, fn.node)
+	for obj := range annotated {
+		ptrs[obj] = &pointerUseInfo{hasCheck: true, trusted: true}
+		trace(fn.node.Pos(), obj, TraceGuard, "trusted: annotated with //nilguard:nonnil")
+	}
+
 	// recordUse registers a "use" of a pointer at the given position. A use
 	// is any selector, method call, or star dereference whose base expression
 	// is a pointer-typed identifier.
-	recordUse := func(id *ast.Ident, pos token.Pos) {
+	recordUse := func(id *ast.Ident, use ast.Expr) {
+		pos := use.Pos()
 		if id == nil {
 			return
 		}
@@ -115,17 +382,25 @@ func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, noLintIndex map[*token.
 			return
 		}
 
-		if _, ok := ptrs[obj]; !ok {
-			ptrs[obj] = &pointerUseInfo{
+		info, ok := ptrs[obj]
+		if !ok {
+			info = &pointerUseInfo{
 				firstPos: pos,
 				hasCheck: false,
 			}
+			ptrs[obj] = info
+		}
+		if info.firstPos == 0 {
+			// The entry may predate the first use, e.g. for a near miss.
+			info.firstPos = pos
 		}
+		info.uses = append(info.uses, pos)
+		trace(pos, obj, TraceUse, "use %s", types.ExprString(use))
 	}
 
 	// markChecked notes that we have seen at least one qualifying nil-check
 	// for the given pointer within this function body.
-	markChecked := func(id *ast.Ident) {
+	markChecked := func(id *ast.Ident, pos token.Pos, reason string) {
 		if id == nil {
 			return
 		}
@@ -144,12 +419,33 @@ func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, noLintIndex map[*token.
 			ptrs[obj] = info
 		}
 		info.hasCheck = true
+		info.trusted = false
+		trace(pos, obj, TraceGuard, "accepted guard: %s", reason)
+	}
+
+	// recordNearMiss notes a nil-check of the given pointer that does not
+	// qualify, to be pointed out if the pointer is reported.
+	recordNearMiss := func(id *ast.Ident, ifStmt *ast.IfStmt, reason string) {
+		if !isPointerIdent(pass.TypesInfo, id) {
+			return
+		}
+		obj := pass.TypesInfo.ObjectOf(id)
+		if obj == nil {
+			return
+		}
+		info, ok := ptrs[obj]
+		if !ok {
+			info = &pointerUseInfo{}
+			ptrs[obj] = info
+		}
+		info.nearMisses = append(info.nearMisses, ifStmt)
+		trace(ifStmt.Cond.Pos(), obj, TraceRejected, "rejected guard: %s", reason)
 	}
 
 	// markCheckedByObj marks a pointer as checked using its types.Object directly.
 	// This is used for type assertion bindings where we have the object but not
 	// necessarily a pointer-typed identifier at the check site.
-	markCheckedByObj := func(obj types.Object) {
+	markCheckedByObj := func(obj types.Object, pos token.Pos, reason string) {
 		if obj == nil {
 			return
 		}
@@ -158,7 +454,11 @@ func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, noLintIndex map[*token.
 			info = &pointerUseInfo{}
 			ptrs[obj] = info
 		}
+		if !info.hasCheck {
+			info.trusted = true
+		}
 		info.hasCheck = true
+		trace(pos, obj, TraceGuard, "accepted guard: %s", reason)
 	}
 
 	// Walk the function body. We explicitly skip nested function literals,
@@ -172,31 +472,39 @@ func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, noLintIndex map[*token.
 
 		case *ast.StarExpr:
 			// *p: record a use if the base is a pointer-typed identifier.
-			if id := baseIdentOf(x.X); id != nil {
-				recordUse(id, x.Pos())
+			if id := BaseIdentOf(x.X); id != nil {
+				recordUse(id, x)
 			}
 
 		case *ast.SelectorExpr:
 			// p.Field or p.Method: record a use if the receiver/base is a
 			// pointer-typed identifier. Parentheses around the base are
-			// handled by baseIdentOf.
-			if id := baseIdentOf(x.X); id != nil {
-				recordUse(id, x.Pos())
+			// handled by BaseIdentOf.
+			if id := BaseIdentOf(x.X); id != nil {
+				recordUse(id, x)
 			}
 
 		case *ast.IfStmt:
-			// Use collectNilChecks to handle both simple and compound conditions:
+			// Use CollectNilChecks to handle both simple and compound conditions:
 			//   if p != nil { ... }
 			//   if p != nil && q != nil { ... }
 			//   if p == nil { return }
 			//   if p == nil || q == nil { return }
-			neqIdents, eqlIdents := collectNilChecks(pass.TypesInfo, x.Cond)
+			neqIdents, eqlIdents := CollectNilChecks(pass.TypesInfo, x.Cond)
+			cond := types.ExprString(x.Cond)
 			for _, id := range neqIdents {
-				markChecked(id)
+				markChecked(id, x.Cond.Pos(), fmt.Sprintf("if %s: non-nil in the then-branch", cond))
 			}
-			if exitsEarly(x.Body) {
-				for _, id := range eqlIdents {
-					markChecked(id)
+			exits := ExitsEarly(pass.TypesInfo, x.Body)
+			for _, id := range eqlIdents {
+				switch {
+				case exits:
+					markChecked(id, x.Cond.Pos(), fmt.Sprintf("if %s: then-branch exits", cond))
+				case repairs(pass, x.Body, pass.TypesInfo.ObjectOf(id)):
+					// if p == nil { p = &T{} }: p is non-nil afterwards.
+					markChecked(id, x.Cond.Pos(), fmt.Sprintf("if %s: then-branch assigns a non-nil value", cond))
+				default:
+					recordNearMiss(id, x, fmt.Sprintf("if %s: then-branch neither exits nor assigns a non-nil value", cond))
 				}
 			}
 
@@ -211,13 +519,8 @@ func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, noLintIndex map[*token.
 					_ = ta // type assertion detected
 					if resultId, ok := x.Lhs[0].(*ast.Ident); ok {
 						obj := pass.TypesInfo.ObjectOf(resultId)
-						if obj != nil {
-							t := obj.Type()
-							if t != nil {
-								if _, isPtr := t.Underlying().(*types.Pointer); isPtr {
-									markCheckedByObj(obj)
-								}
-							}
+						if obj != nil && IsPointerType(obj.Type()) {
+							markCheckedByObj(obj, x.Pos(), "comma-ok type assertion")
 						}
 					}
 				}
@@ -240,7 +543,7 @@ func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, noLintIndex map[*token.
 						if implObj == nil {
 							continue
 						}
-						markCheckedByObj(implObj)
+						markCheckedByObj(implObj, cc.Pos(), "type switch case binding")
 					}
 				}
 			}
@@ -249,31 +552,191 @@ func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, noLintIndex map[*token.
 		return true
 	})
 
+	// Under the standard and strict profiles a guard must dominate each use,
+	// rather than merely exist somewhere in the function.
+	var flow *flowChecker
+	var unguarded map[nilTarget][]token.Pos
+	if profile := st.cfg.profile(); profile != ProfileLenient {
+		flow = &flowChecker{pass: pass, strict: profile == ProfileStrict}
+		unguarded = flow.check(body, inherited, annotated)
+	}
+
+	// reported returns the uses of obj to report, or nil if it is checked.
+	reported := func(obj types.Object, info *pointerUseInfo) []token.Pos {
+		if flow != nil {
+			return unguarded[nilTarget{root: obj}]
+		}
+		if info.hasCheck {
+			return nil
+		}
+		return info.uses
+	}
+
+	// Count the uses, and how they are guarded or suppressed, for
+	// Result.Stats.
+	stats := FuncStats{Func: fn.name, FuncPos: fn.node.Pos(), FuncEnd: fn.node.End()}
+	for obj, info := range ptrs {
+		if info.firstPos == 0 {
+			continue
+		}
+		unguardedUses := reported(obj, info)
+		stats.Pointers++
+		stats.Uses += len(info.uses)
+		for _, pos := range info.uses {
+			switch {
+			case slices.Contains(unguardedUses, pos):
+				if st.covered(pos, obj.Name()) {
+					stats.SuppressedUses++
+				}
+			case flow != nil:
+				stats.GuardedUses++
+				if slices.Contains(flow.trusted[nilTarget{root: obj}], pos) {
+					stats.TrustedUses++
+				}
+			default:
+				stats.GuardedUses++
+				if info.trusted {
+					stats.TrustedUses++
+				}
+			}
+		}
+	}
+	if stats.Pointers > 0 {
+		st.result.Stats = append(st.result.Stats, stats)
+	}
+
+	// Conclude the trace with a verdict per used pointer, in order of first
+	// use.
+	if tr != nil {
+		var used []types.Object
+		for obj, info := range ptrs {
+			if info.firstPos != 0 {
+				used = append(used, obj)
+			}
+		}
+		sort.Slice(used, func(i, j int) bool { return ptrs[used[i]].firstPos < ptrs[used[j]].firstPos })
+		for _, obj := range used {
+			uses := reported(obj, ptrs[obj])
+			switch {
+			case flow != nil && len(uses) == 0:
+				trace(ptrs[obj].firstPos, obj, TraceVerdict, "checked: every use is dominated by a guard")
+			case flow != nil:
+				trace(uses[0], obj, TraceVerdict, "unchecked: %d of %d uses are not dominated by a guard, so they are reported",
+					len(uses), len(ptrs[obj].uses))
+			case len(uses) == 0:
+				trace(ptrs[obj].firstPos, obj, TraceVerdict, "checked: a guard was accepted")
+			default:
+				trace(ptrs[obj].firstPos, obj, TraceVerdict, "unchecked: no guard was accepted, so the use is reported")
+			}
+		}
+	}
+
+	// emit reports the unguarded uses of pointer, whose root variable is
+	// root, as selected by Config.Report.
+	emit := func(pointer string, root types.Object, uses []token.Pos, msg string, related []analysis.RelatedInformation) {
+		switch st.cfg.Report {
+		case ReportAll:
+			// Report every use site.
+			for _, pos := range uses {
+				st.report(fn, pointer, analysis.Diagnostic{
+					Pos:            pos,
+					Category:       RuleUnchecked,
+					Message:        msg,
+					Related:        related,
+					SuggestedFixes: uncheckedFixes(pass, fn, body, pos, pointer, root),
+				})
+			}
+
+		case ReportSummary:
+			// Report the first use, listing the others as related information.
+			d := analysis.Diagnostic{
+				Pos:            uses[0],
+				Category:       RuleUnchecked,
+				Message:        msg,
+				Related:        slices.Clip(related),
+				SuggestedFixes: uncheckedFixes(pass, fn, body, uses[0], pointer, root),
+			}
+			for _, pos := range uses[1:] {
+				d.Related = append(d.Related, analysis.RelatedInformation{Pos: pos, Message: "also used here"})
+			}
+			st.report(fn, pointer, d)
+
+		default:
+			// Report a single diagnostic per pointer at its first use position.
+			st.report(fn, pointer, analysis.Diagnostic{
+				Pos:            uses[0],
+				Category:       RuleUnchecked,
+				Message:        msg,
+				Related:        related,
+				SuggestedFixes: uncheckedFixes(pass, fn, body, uses[0], pointer, root),
+			})
+		}
+	}
+
 	// Emit diagnostics for any pointer that was used but never nil-checked.
 	for obj, info := range ptrs {
 		if info.firstPos == 0 {
 			// Pointer never used; nothing to report.
 			continue
 		}
-		if info.hasCheck {
-			// A qualifying nil-check exists; our v1 policy is satisfied.
+		uses := reported(obj, info)
+		if len(uses) == 0 {
+			// The policy of the profile is satisfied.
 			continue
 		}
 
-		// Skip diagnostics for files outside the current package's file set.
-		if !isFileInPackage(pass.Fset, fileIndex, info.firstPos) {
-			continue
+		// Explain where the pointer comes from and point at its declaration
+		// and at any nil-checks that did not qualify.
+		origin := describeOrigin(pass.TypesInfo, fn, body, obj)
+		msg := fmt.Sprintf("pointer %q is used in this function but never nil-checked %s",
+			obj.Name(), originSuffix(pass.Pkg, origin, obj.Type()))
+		if flow != nil && (info.hasCheck || len(uses) < len(info.uses)) {
+			msg = fmt.Sprintf("pointer %q is used where no nil check guards it %s",
+				obj.Name(), originSuffix(pass.Pkg, origin, obj.Type()))
 		}
-
-		// Respect per-line //nolint:nilguard directives.
-		if hasNoLintNilguard(pass.Fset, noLintIndex, info.firstPos) {
-			continue
+		related := []analysis.RelatedInformation{{
+			Pos:     obj.Pos(),
+			Message: fmt.Sprintf("%s declared here", obj.Name()),
+		}}
+		for _, ifStmt := range info.nearMisses {
+			related = append(related, analysis.RelatedInformation{
+				Pos:     ifStmt.Cond.Pos(),
+				Message: "nil check neither exits nor assigns a non-nil value, so it does not guard the uses",
+			})
 		}
 
-		// Report a single diagnostic per pointer at its first use position.
-		pass.Reportf(info.firstPos,
-			"pointer %q is used in this function but never nil-checked",
-			obj.Name())
+		// Near misses followed by a use are likely bugs: the nil case is
+		// noticed, but execution continues into the use anyway.
+		for _, ifStmt := range info.nearMisses {
+			for _, pos := range uses {
+				if pos < ifStmt.End() {
+					continue
+				}
+				st.report(fn, obj.Name(), analysis.Diagnostic{
+					Pos:      ifStmt.Cond.Pos(),
+					End:      ifStmt.Cond.End(),
+					Category: RuleNearMiss,
+					Message: fmt.Sprintf("nil check of %q neither exits nor assigns a non-nil value, so %s may still be nil when used",
+						obj.Name(), obj.Name()),
+					Related:        []analysis.RelatedInformation{{Pos: pos, Message: "used here"}},
+					SuggestedFixes: nearMissFixes(pass, fn, ifStmt),
+				})
+				break
+			}
+		}
+		emit(obj.Name(), obj, uses, msg, related)
 	}
 
+	// Under the strict profile, also report pointers loaded from fields and
+	// elements that are used without a guard, e.g. s.conn.Close().
+	for t, uses := range unguarded {
+		if t.path == "" {
+			continue
+		}
+		e := flow.exprs[t]
+		origin := valueOrigin(pass.TypesInfo, []ast.Expr{e}, 1, 0)
+		msg := fmt.Sprintf("pointer %q is used where no nil check guards it %s",
+			t.name(), originSuffix(pass.Pkg, origin, pass.TypesInfo.TypeOf(e)))
+		emit(t.name(), t.root, uses, msg, nil)
+	}
 }
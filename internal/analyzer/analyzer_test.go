@@ -1,24 +1,288 @@
 package analyzer
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
-// TestNilguard runs the nilguard Analyzer against the packages under the
-// local testdata directory. The testdata layout follows the convention:
-//
-//	testdata/src/ok   - packages where no diagnostics are expected
-//	testdata/src/bad  - packages where diagnostics are annotated with // want
-//
-// The testdata layout uses // want markers to assert expected diagnostics.
+// TestNilguard runs the package-level Analyzer, as wired into the command
+// and the plugins, against the packages under the local testdata directory.
+// The packages mark expected diagnostics with // want; testdata/src/ok
+// expects none.
 func TestNilguard(t *testing.T) {
-	// analysistest.TestData locates the "testdata" directory relative to
-	// the package containing this test file.
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "ok", "bad", "nolint", "nilassign", "generics", "generated", "origins", "repair")
+}
+
+// TestPolicies runs differently configured Analyzers in parallel, each
+// against the testdata packages written for its policy. Every instance comes
+// from New and owns its flag set, so the subtests share no state.
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		pkgs []string
+	}{
+		{"default", Config{}, []string{"ok", "bad", "nolint", "nilassign", "generics", "generated", "origins", "repair"}},
+		{"closure-guards", Config{ClosureGuards: true}, []string{"closures"}},
+		{"exclude-tests", Config{ExcludeTests: true}, []string{"testfiles"}},
+		{"require-reason", Config{RequireReason: true}, []string{"reasons"}},
+		{"include-generated", Config{IncludeGenerated: true}, []string{"included"}},
+		{"report-all", Config{Report: ReportAll}, []string{"reportall"}},
+		{"standard", Config{Profile: ProfileStandard}, []string{"standard"}},
+		{"strict", Config{Profile: ProfileStrict}, []string{"strict"}},
+	}
 	testdata := analysistest.TestData()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			analysistest.Run(t, testdata, New(tt.cfg), tt.pkgs...)
+		})
+	}
+}
+
+// TestReportSummary verifies that -report=summary lists every other use of
+// the pointer as related information.
+func TestReportSummary(t *testing.T) {
+	t.Parallel()
+	results := analysistest.Run(t, analysistest.TestData(), New(Config{Report: ReportSummary}), "reportsummary")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	findings := results[0].Result.(*Result).Findings
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	var uses int
+	for _, r := range findings[0].Diagnostic.Related {
+		if r.Message == "also used here" {
+			uses++
+			if r.Pos <= findings[0].Diagnostic.Pos {
+				t.Errorf("related use %v precedes the reported use", r.Pos)
+			}
+		}
+	}
+	if uses != 2 {
+		t.Errorf("got %d related uses, want 2", uses)
+	}
+}
+
+// TestRelatedInformation verifies that unchecked-pointer diagnostics point at
+// the pointer's declaration and at nil-checks that did not qualify.
+func TestRelatedInformation(t *testing.T) {
+	t.Parallel()
+	results := analysistest.Run(t, analysistest.TestData(), New(Config{}), "origins")
+	for _, r := range results {
+		for _, f := range r.Result.(*Result).Findings {
+			if f.Func != "nearMiss" || f.Diagnostic.Category != RuleUnchecked {
+				continue
+			}
+			var got []string
+			for _, rel := range f.Diagnostic.Related {
+				got = append(got, fmt.Sprintf("%d: %s", r.Pass.Fset.Position(rel.Pos).Line, rel.Message))
+			}
+			want := []string{
+				"68: p declared here",
+				"69: nil check neither exits nor assigns a non-nil value, so it does not guard the uses",
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("nearMiss related information:\ngot  %q\nwant %q", got, want)
+			}
+			return
+		}
+	}
+	t.Fatal("no finding in nearMiss")
+}
+
+// TestTrace verifies that Config.Trace records uses, rejected guards and
+// verdicts in source order.
+func TestTrace(t *testing.T) {
+	t.Parallel()
+	results := analysistest.Run(t, analysistest.TestData(), New(Config{Trace: true}), "origins")
+	for _, r := range results {
+		for _, tr := range r.Result.(*Result).Traces {
+			if tr.Func != "nearMiss" {
+				continue
+			}
+			var got []string
+			for _, e := range tr.Events {
+				got = append(got, fmt.Sprintf("%d %s %s: %s", r.Pass.Fset.Position(e.Pos).Line, e.Kind, e.Pointer, e.Message))
+			}
+			want := []string{
+				"69 rejected p: rejected guard: if p == nil: then-branch neither exits nor assigns a non-nil value",
+				"72 use p: use p.X",
+				"72 verdict p: unchecked: no guard was accepted, so the use is reported",
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("nearMiss trace:\ngot  %q\nwant %q", got, want)
+			}
+			return
+		}
+	}
+	t.Fatal("no trace for nearMiss")
+}
+
+// TestInvalidReport verifies that an unknown -report mode is an error.
+func TestInvalidReport(t *testing.T) {
+	a := New(Config{})
+	if err := a.Flags.Set("report", "every"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Run(&analysis.Pass{}); err == nil || !strings.Contains(err.Error(), "invalid -report") {
+		t.Errorf("Run with -report=every: got error %v, want invalid -report", err)
+	}
+}
+
+// TestSuggestedFixes verifies the nil guard, //nolint, return and directive
+// removal fixes against fixes.go.golden, one section per fix message.
+func TestSuggestedFixes(t *testing.T) {
+	t.Parallel()
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), New(Config{}), "fixes")
+}
+
+// TestSuppressedFindings verifies that diagnostics silenced by directives are
+// recorded in Result.Suppressed with the directive and its reason, and that
+// findings describe the pointer type and use.
+func TestSuppressedFindings(t *testing.T) {
+	t.Parallel()
+	results := analysistest.Run(t, analysistest.TestData(), New(Config{RequireReason: true}), "reasons")
+	res := results[0].Result.(*Result)
+	if len(res.Suppressed) == 0 {
+		t.Fatal("no suppressed findings")
+	}
+	for _, f := range res.Suppressed {
+		if f.Suppression == nil || f.Suppression.Directive == "" {
+			t.Errorf("suppressed finding at %v has no directive", f.Diagnostic.Pos)
+		}
+	}
+	for _, f := range append(res.Findings, res.Suppressed...) {
+		if f.Diagnostic.Category == RuleUnchecked && (f.PointerType == "" || f.UseKind == "") {
+			t.Errorf("%s finding about %s has pointer type %q and use kind %q", f.Diagnostic.Category, f.Pointer, f.PointerType, f.UseKind)
+		}
+	}
+}
+
+// TestStats verifies the per-function use counts of Result.Stats under the
+// lenient and standard profiles.
+func TestStats(t *testing.T) {
+	tests := []struct {
+		profile string
+		pkg     string // testdata annotated for the profile
+		want    []string
+	}{
+		{"", "stats", []string{
+			"Guarded 1 2 2 0 0",
+			"Unguarded 2 3 1 0 0",
+			"Late 1 2 2 0 0",
+			"Asserted 1 1 1 0 1",
+			"Constructed 1 1 0 0 0",
+			"Suppressed 1 1 0 1 0",
+			"Annotated 1 1 1 0 1",
+		}},
+		{ProfileStandard, "statsstandard", []string{
+			"Guarded 1 2 2 0 0",
+			"Unguarded 2 3 1 0 0",
+			"Late 1 2 1 0 0",
+			"Asserted 1 1 1 0 1",
+			"Constructed 1 1 1 0 1",
+			"Suppressed 1 1 0 1 0",
+			"Annotated 1 1 1 0 1",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			t.Parallel()
+			results := analysistest.Run(t, analysistest.TestData(), New(Config{Profile: tt.profile}), tt.pkg)
+			var got []string
+			for _, s := range results[0].Result.(*Result).Stats {
+				got = append(got, fmt.Sprintf("%s %d %d %d %d %d", s.Func, s.Pointers, s.Uses, s.GuardedUses, s.SuppressedUses, s.TrustedUses))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("stats (func pointers uses guarded suppressed trusted):\ngot  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBadAnnotation verifies that annotations without effect are reported
+// under their own rule rather than as unused suppressions.
+func TestBadAnnotation(t *testing.T) {
+	t.Parallel()
+	results := analysistest.Run(t, analysistest.TestData(), New(Config{}), "nolint")
+	n := 0
+	for _, f := range results[0].Result.(*Result).Findings {
+		if strings.HasPrefix(f.Diagnostic.Message, "annotation ") {
+			n++
+			if f.Diagnostic.Category != RuleBadAnnotation || f.Severity != SeverityNote {
+				t.Errorf("%q reported as %s with severity %s", f.Diagnostic.Message, f.Diagnostic.Category, f.Severity)
+			}
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d annotation findings, want 2", n)
+	}
+}
+
+// TestInvalidProfile verifies that an unknown -profile is rejected.
+func TestInvalidProfile(t *testing.T) {
+	a := New(Config{Profile: "paranoid"})
+	if _, err := a.Run(&analysis.Pass{}); err == nil || !strings.Contains(err.Error(), "invalid -profile") {
+		t.Errorf("Run with -profile=paranoid: got error %v, want invalid -profile", err)
+	}
+}
+
+// TestSeverity verifies that findings carry the severity of their rule under
+// the selected profile.
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		profile string
+		want    map[string]string
+	}{
+		{"", map[string]string{RuleUnchecked: SeverityWarning, RuleNearMiss: SeverityWarning, RuleNilAssign: SeverityError}},
+		{ProfileStandard, map[string]string{RuleUnchecked: SeverityWarning, RuleNearMiss: SeverityError, RuleNilAssign: SeverityError}},
+		{ProfileStrict, map[string]string{RuleUnchecked: SeverityError, RuleNearMiss: SeverityError, RuleNilAssign: SeverityError}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			t.Parallel()
+			results := analysistest.Run(t, analysistest.TestData(), New(Config{Profile: tt.profile}), "severity")
+			seen := make(map[string]bool)
+			for _, r := range results {
+				for _, f := range r.Result.(*Result).Findings {
+					seen[f.Diagnostic.Category] = true
+					if want := tt.want[f.Diagnostic.Category]; f.Severity != want {
+						t.Errorf("%s finding at %v: severity %q, want %q",
+							f.Diagnostic.Category, r.Pass.Fset.Position(f.Diagnostic.Pos), f.Severity, want)
+					}
+				}
+			}
+			for rule := range tt.want {
+				if !seen[rule] {
+					t.Errorf("no %s finding", rule)
+				}
+			}
+		})
+	}
+}
 
-	// We run the analyzer on both the "ok" and "bad" packages. analysistest
-	// will compare the analyzer's diagnostics with the // want annotations.
-	analysistest.Run(t, testdata, Analyzer, "ok", "bad", "nolint")
+// TestNewIndependentFlags verifies that setting a flag on one Analyzer does
+// not affect another.
+func TestNewIndependentFlags(t *testing.T) {
+	a, b := New(Config{}), New(Config{ExcludeTests: true})
+	if err := a.Flags.Set("closure-guards", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Flags.Lookup("closure-guards").Value.String(); got != "false" {
+		t.Errorf("b closure-guards = %s, want false", got)
+	}
+	if got := b.Flags.Lookup("exclude-tests").Value.String(); got != "true" {
+		t.Errorf("b exclude-tests = %s, want true", got)
+	}
+	if got := Analyzer.Flags.Lookup("closure-guards").Value.String(); got != "false" {
+		t.Errorf("Analyzer closure-guards = %s, want false", got)
+	}
 }
@@ -1,27 +1,30 @@
 package analyzer
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"golang.org/x/tools/go/analysis"
 )
 
-// baseIdentOf strips away syntactic noise like parentheses and returns the
+// BaseIdentOf strips away syntactic noise like parentheses and returns the
 // underlying *ast.Ident if the expression is a simple identifier (possibly
 // wrapped in parentheses). If the base is not an identifier, it returns nil.
 //
 // Examples:
 //
-//	baseIdentOf(p)      -> ident "p"
-//	baseIdentOf((p))    -> ident "p"
-//	baseIdentOf((*p).X) -> nil (we only call this on the base expr)
+//	BaseIdentOf(p)      -> ident "p"
+//	BaseIdentOf((p))    -> ident "p"
+//	BaseIdentOf((*p).X) -> nil (we only call this on the base expr)
 //
 // Note: This helper is intentionally conservative and only recognizes simple
 // identifiers as bases. More complex expressions are left to later versions.
-func baseIdentOf(expr ast.Expr) *ast.Ident {
+func BaseIdentOf(expr ast.Expr) *ast.Ident {
 	for {
 		switch e := expr.(type) {
 		case *ast.Ident:
@@ -34,20 +37,127 @@ func baseIdentOf(expr ast.Expr) *ast.Ident {
 	}
 }
 
-// isPointerIdent reports whether id has a pointer underlying type according
-// to the provided types.Info. If type information is missing, it returns false.
+// funcDeclName returns the display name of a function declaration: "F" for a
+// plain function, "T.M" or "(*T).M" for a method. Type arguments of generic
+// receivers are omitted.
+func funcDeclName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		return "(*" + recvTypeName(star.X) + ")." + fn.Name.Name
+	}
+	return recvTypeName(recv) + "." + fn.Name.Name
+}
+
+// recvTypeName returns the name of a receiver base type expression, dropping
+// parentheses and type parameters.
+func recvTypeName(e ast.Expr) string {
+	for {
+		switch x := e.(type) {
+		case *ast.Ident:
+			return x.Name
+		case *ast.ParenExpr:
+			e = x.X
+		case *ast.StarExpr:
+			return "*" + recvTypeName(x.X)
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.IndexListExpr:
+			e = x.X
+		default:
+			return "?"
+		}
+	}
+}
+
+// enclosingFunc returns the innermost function declaration or literal on the
+// traversal stack, or nil if there is none.
+func enclosingFunc(stack []ast.Node) ast.Node {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return stack[i]
+		}
+	}
+	return nil
+}
+
+// isPointerIdent reports whether id has a pointer type according to the
+// provided types.Info (see IsPointerType). If type information is missing, it
+// returns false.
 func isPointerIdent(info *types.Info, id *ast.Ident) bool {
 	if id == nil {
 		return false
 	}
-	t := info.TypeOf(id)
+	return IsPointerType(info.TypeOf(id))
+}
+
+// IsPointerType reports whether t is a pointer for nilguard's purposes: either
+// its underlying type is a pointer, or it is a type parameter whose core type
+// is a pointer, such as P in
+//
+//	func F[T any, P interface{ *T; M() }](p P)
+func IsPointerType(t types.Type) bool {
 	if t == nil {
 		return false
 	}
+	if tp, ok := t.(*types.TypeParam); ok {
+		return corePointer(tp.Constraint()) != nil
+	}
 	_, ok := t.Underlying().(*types.Pointer)
 	return ok
 }
 
+// corePointer returns the core type of the type set described by the
+// constraint t if that core type is a pointer, and nil otherwise.
+//
+// Following the core type rules, every type in the type set must share the
+// same underlying pointer type. Method-only constraints (including any) have
+// no core type.
+func corePointer(t types.Type) *types.Pointer {
+	switch u := t.(type) {
+	case *types.TypeParam:
+		return corePointer(u.Constraint())
+	case *types.Union:
+		var core *types.Pointer
+		for i := 0; i < u.Len(); i++ {
+			ptr, ok := u.Term(i).Type().Underlying().(*types.Pointer)
+			if !ok || (core != nil && !types.Identical(core, ptr)) {
+				return nil
+			}
+			core = ptr
+		}
+		return core
+	}
+
+	iface, ok := t.Underlying().(*types.Interface)
+	if !ok {
+		ptr, _ := t.Underlying().(*types.Pointer)
+		return ptr
+	}
+	if iface.IsMethodSet() {
+		return nil
+	}
+
+	// The type set is the intersection of the embedded elements; each element
+	// that restricts the type set must agree on the core type.
+	var core *types.Pointer
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		elem := iface.EmbeddedType(i)
+		if ei, ok := elem.Underlying().(*types.Interface); ok && ei.IsMethodSet() {
+			continue
+		}
+		ptr := corePointer(elem)
+		if ptr == nil || (core != nil && !types.Identical(core, ptr)) {
+			return nil
+		}
+		core = ptr
+	}
+	return core
+}
+
 // isNil reports whether e is the predeclared identifier "nil".
 func isNil(e ast.Expr) bool {
 	id, ok := e.(*ast.Ident)
@@ -82,7 +192,7 @@ func binopPtrNil(info *types.Info, e ast.Expr, want token.Token) *ast.Ident {
 	return nil
 }
 
-// collectNilChecks extracts all pointer identifiers that are nil-checked
+// CollectNilChecks extracts all pointer identifiers that are nil-checked
 // within a (possibly compound) boolean expression. It recognizes:
 //
 //   - Simple: p != nil, p == nil
@@ -92,12 +202,12 @@ func binopPtrNil(info *types.Info, e ast.Expr, want token.Token) *ast.Ident {
 // Returns two slices: neqIdents for != nil checks, eqlIdents for == nil checks.
 // The caller decides how to use them (e.g. markChecked with or without
 // early-exit requirement).
-func collectNilChecks(info *types.Info, e ast.Expr) (neqIdents, eqlIdents []*ast.Ident) {
+func CollectNilChecks(info *types.Info, e ast.Expr) (neqIdents, eqlIdents []*ast.Ident) {
 	switch x := e.(type) {
 	case *ast.BinaryExpr:
 		if x.Op == token.LAND || x.Op == token.LOR {
-			lNeq, lEql := collectNilChecks(info, x.X)
-			rNeq, rEql := collectNilChecks(info, x.Y)
+			lNeq, lEql := CollectNilChecks(info, x.X)
+			rNeq, rEql := CollectNilChecks(info, x.Y)
 			return append(lNeq, rNeq...), append(lEql, rEql...)
 		}
 		if id := binopPtrNil(info, e, token.NEQ); id != nil {
@@ -107,12 +217,53 @@ func collectNilChecks(info *types.Info, e ast.Expr) (neqIdents, eqlIdents []*ast
 			return nil, []*ast.Ident{id}
 		}
 	case *ast.ParenExpr:
-		return collectNilChecks(info, x.X)
+		return CollectNilChecks(info, x.X)
 	}
 	return nil, nil
 }
 
-// exitsEarly reports whether the given block ends with an unconditional exit
+// NonNilWhenTrue returns the pointer identifiers that are known to be non-nil
+// whenever e evaluates to true. Unlike CollectNilChecks it only looks through
+// && chains, so the result is precise enough for position-sensitive checks:
+//
+//	p != nil
+//	p != nil && q != nil && p.X > 0
+func NonNilWhenTrue(info *types.Info, e ast.Expr) []*ast.Ident {
+	switch x := e.(type) {
+	case *ast.ParenExpr:
+		return NonNilWhenTrue(info, x.X)
+	case *ast.BinaryExpr:
+		if x.Op == token.LAND {
+			return append(NonNilWhenTrue(info, x.X), NonNilWhenTrue(info, x.Y)...)
+		}
+		if id := binopPtrNil(info, x, token.NEQ); id != nil {
+			return []*ast.Ident{id}
+		}
+	}
+	return nil
+}
+
+// NonNilWhenFalse returns the pointer identifiers that are known to be non-nil
+// whenever e evaluates to false. It only looks through || chains:
+//
+//	p == nil
+//	p == nil || q == nil || done
+func NonNilWhenFalse(info *types.Info, e ast.Expr) []*ast.Ident {
+	switch x := e.(type) {
+	case *ast.ParenExpr:
+		return NonNilWhenFalse(info, x.X)
+	case *ast.BinaryExpr:
+		if x.Op == token.LOR {
+			return append(NonNilWhenFalse(info, x.X), NonNilWhenFalse(info, x.Y)...)
+		}
+		if id := binopPtrNil(info, x, token.EQL); id != nil {
+			return []*ast.Ident{id}
+		}
+	}
+	return nil
+}
+
+// ExitsEarly reports whether the given block ends with an unconditional exit
 // from the current function according to our v1 policy.
 //
 // For v1, we consider the following as "early exits":
@@ -120,11 +271,13 @@ func collectNilChecks(info *types.Info, e ast.Expr) (neqIdents, eqlIdents []*ast
 //   - return
 //   - panic(...)
 //   - branch statements (break / continue / goto)
+//   - calls that do not return, such as os.Exit(...), log.Fatal(...) or
+//     t.Fatal(...) (see callMayReturn), when info is not nil
 //
 // This is intentionally conservative and coarse: treating break/continue/goto
 // as exits simplifies the reasoning without affecting the core nil-check rule
 // in real-world code.
-func exitsEarly(b *ast.BlockStmt) bool {
+func ExitsEarly(info *types.Info, b *ast.BlockStmt) bool {
 	if b == nil || len(b.List) == 0 {
 		return false
 	}
@@ -141,79 +294,20 @@ func exitsEarly(b *ast.BlockStmt) bool {
 		return s.Tok == token.GOTO || s.Tok == token.BREAK || s.Tok == token.CONTINUE
 
 	case *ast.ExprStmt:
+		call, ok := ast.Unparen(s.X).(*ast.CallExpr)
+		if !ok {
+			return false
+		}
 		// Match panic(...)
-		if call, ok := s.X.(*ast.CallExpr); ok {
-			if id, ok := call.Fun.(*ast.Ident); ok && id.Name == "panic" {
-				return true
-			}
+		if id, ok := call.Fun.(*ast.Ident); ok && id.Name == "panic" {
+			return true
 		}
+		return info != nil && !callMayReturn(info, call)
 	}
 
 	return false
 }
 
-// buildNoLintIndex constructs an index of lines that contain a nolint directive
-// mentioning "nilguard", grouped by file.
-//
-// The result maps each *token.File to a set of line numbers (1-based) for
-// which diagnostics from nilguard should be suppressed.
-//
-// We only consider comment texts that contain both "nolint" and "nilguard"
-// (case-insensitive), which supports common forms such as:
-//
-//	//nolint:nilguard
-//	// nolint:nilguard
-//	// nolint:foo,nilguard,bar
-//
-// but does not treat bare //nolint as sufficient to suppress nilguard.
-func buildNoLintIndex(pass *analysis.Pass) map[*token.File]map[int]bool {
-	index := make(map[*token.File]map[int]bool)
-
-	for _, f := range pass.Files {
-		if f == nil {
-			continue
-		}
-
-		tf := pass.Fset.File(f.Pos())
-		if tf == nil {
-			continue
-		}
-
-		// Lazily allocate the per-file map when we actually find a relevant comment.
-		var lines map[int]bool
-
-		for _, cg := range f.Comments {
-			if cg == nil {
-				continue
-			}
-			for _, c := range cg.List {
-				if c == nil {
-					continue
-				}
-				// Normalize the comment text for a simple substring check.
-				text := strings.ToLower(c.Text)
-
-				// Quick filter: require both "nolint" and "nilguard".
-				if !strings.Contains(text, "nolint") || !strings.Contains(text, "nilguard") {
-					continue
-				}
-
-				if lines == nil {
-					lines = make(map[int]bool)
-					index[tf] = lines
-				}
-
-				line := tf.Line(c.Slash)
-				if line > 0 {
-					lines[line] = true
-				}
-			}
-		}
-	}
-
-	return index
-}
-
 // buildFileIndex records the set of file paths in the current package.
 func buildFileIndex(pass *analysis.Pass) map[string]bool {
 	index := make(map[string]bool)
@@ -248,32 +342,37 @@ func isFileInPackage(fset *token.FileSet, index map[string]bool, pos token.Pos)
 	return index[tf.Name()]
 }
 
-// hasNoLintNilguard reports whether the source line corresponding to pos in
-// the given file set is marked as having a nolint directive for nilguard in
-// the provided index.
+// fileFilter compiles the OnlyFiles and ExcludeFiles expressions of cfg and
+// returns a function reporting whether functions in a file must be skipped.
 //
-// The index is expected to be produced by buildNoLintIndex.
-func hasNoLintNilguard(fset *token.FileSet, index map[*token.File]map[int]bool, pos token.Pos) bool {
-	if fset == nil {
-		return false
-	}
-
-	tf := fset.File(pos)
-	if tf == nil {
-		return false
-	}
-
-	lines, ok := index[tf]
-	if !ok {
-		return false
+// The expressions are matched against the file name as recorded in the file
+// set (an execroot-relative path under nogo), following nogo's semantics: a
+// file is analyzed only if it matches OnlyFiles (when set) and does not match
+// ExcludeFiles (when set).
+func fileFilter(cfg Config) (func(*token.File) bool, error) {
+	var only, exclude *regexp.Regexp
+	var err error
+	if cfg.OnlyFiles != "" {
+		if only, err = regexp.Compile(cfg.OnlyFiles); err != nil {
+			return nil, fmt.Errorf("invalid -only-files: %w", err)
+		}
 	}
-
-	line := tf.Line(pos)
-	if line <= 0 {
-		return false
+	if cfg.ExcludeFiles != "" {
+		if exclude, err = regexp.Compile(cfg.ExcludeFiles); err != nil {
+			return nil, fmt.Errorf("invalid -exclude-files: %w", err)
+		}
 	}
 
-	return lines[line]
+	return func(tf *token.File) bool {
+		if tf == nil {
+			return false
+		}
+		name := filepath.ToSlash(tf.Name())
+		if only != nil && !only.MatchString(name) {
+			return true
+		}
+		return exclude != nil && exclude.MatchString(name)
+	}, nil
 }
 
 // isTestFile reports whether the file containing pos ends with _test.go.
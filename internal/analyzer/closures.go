@@ -0,0 +1,235 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// inheritedGuards returns the captured pointer variables that are treated as
+// nil-checked on entry to lit when -closure-guards is enabled.
+//
+// stack is the inspector traversal stack ending in lit. A captured variable
+// qualifies when all of the following hold:
+//
+//   - it is a local variable (or parameter) declared outside lit,
+//   - an enclosing function guards it before lit is created, either by
+//     creating lit inside the then-branch of `if p != nil { ... }` or by
+//     creating lit after `if p == nil { return }` in the same block, and
+//   - it is not reassigned anywhere in the outermost enclosing function after
+//     that guard, nor before the guard in a loop that contains it, nor
+//     anywhere in another function literal, which may run at any time.
+//     Taking the address of the variable anywhere disqualifies it, since the
+//     pointer may be reassigned through the alias.
+func inheritedGuards(info *types.Info, lit *ast.FuncLit, stack []ast.Node) map[types.Object]bool {
+	outer := outermostFuncBody(stack)
+	if outer == nil {
+		return nil
+	}
+
+	var inherited map[types.Object]bool
+	for obj := range capturedPointers(info, lit) {
+		guard := closureGuardPos(info, outer, obj, lit)
+		if guard == token.NoPos {
+			continue
+		}
+		if reassignedAfter(info, outer, obj, guard, lit) {
+			continue
+		}
+		if inherited == nil {
+			inherited = make(map[types.Object]bool)
+		}
+		inherited[obj] = true
+	}
+	return inherited
+}
+
+// outermostFuncBody returns the body of the outermost function declaration or
+// literal on the traversal stack, or nil if there is none.
+func outermostFuncBody(stack []ast.Node) *ast.BlockStmt {
+	for _, n := range stack {
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			return fn.Body
+		case *ast.FuncLit:
+			return fn.Body
+		}
+	}
+	return nil
+}
+
+// capturedPointers returns the pointer-typed local variables that are
+// referenced inside lit but declared outside of it. Package-level variables
+// are never considered captured, since any function may reassign them.
+func capturedPointers(info *types.Info, lit *ast.FuncLit) map[types.Object]bool {
+	captured := make(map[types.Object]bool)
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		v, ok := info.Uses[id].(*types.Var)
+		if !ok || v.IsField() || !isPointerIdent(info, id) {
+			return true
+		}
+		if v.Pos() >= lit.Pos() && v.Pos() < lit.End() {
+			// Declared inside the literal itself.
+			return true
+		}
+		if v.Pkg() == nil || v.Parent() == nil || v.Parent() == v.Pkg().Scope() {
+			return true
+		}
+		captured[v] = true
+		return true
+	})
+	return captured
+}
+
+// closureGuardPos returns the position of the latest nil-check of obj in body
+// that guards the creation of lit, or token.NoPos if there is none.
+func closureGuardPos(info *types.Info, body *ast.BlockStmt, obj types.Object, lit *ast.FuncLit) token.Pos {
+	guard := token.NoPos
+	found := func(pos token.Pos) {
+		if pos > guard {
+			guard = pos
+		}
+	}
+
+	// stmtsGuard handles `if p == nil { return }` followed, in the same
+	// statement list, by a statement that contains lit.
+	stmtsGuard := func(list []ast.Stmt) {
+		for i, stmt := range list {
+			ifs, ok := stmt.(*ast.IfStmt)
+			if !ok || !ExitsEarly(info, ifs.Body) || !identsRefer(info, NonNilWhenFalse(info, ifs.Cond), obj) {
+				continue
+			}
+			for _, later := range list[i+1:] {
+				if containsNode(later, lit) {
+					found(ifs.Pos())
+				}
+			}
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.IfStmt:
+			// if p != nil { lit }
+			if containsNode(x.Body, lit) && identsRefer(info, NonNilWhenTrue(info, x.Cond), obj) {
+				found(x.Pos())
+			}
+			// if p == nil { ... } else { lit }
+			if x.Else != nil && containsNode(x.Else, lit) && identsRefer(info, NonNilWhenFalse(info, x.Cond), obj) {
+				found(x.Pos())
+			}
+		case *ast.BlockStmt:
+			stmtsGuard(x.List)
+		case *ast.CaseClause:
+			stmtsGuard(x.Body)
+		case *ast.CommClause:
+			stmtsGuard(x.Body)
+		}
+		return true
+	})
+
+	return guard
+}
+
+// reassignedAfter reports whether obj is assigned anywhere in body after pos,
+// or has its address taken anywhere in body. An assignment before pos counts
+// too if a loop around pos repeats it, since a closure created in one
+// iteration observes the assignments of the next, or if it is inside a
+// function literal other than lit, since that literal may run after lit is
+// created.
+func reassignedAfter(info *types.Info, body *ast.BlockStmt, obj types.Object, pos token.Pos, lit *ast.FuncLit) bool {
+	// repeated holds the parts of the loops containing pos that run on
+	// every iteration, and the other function literals.
+	var repeated []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			if x != lit {
+				repeated = append(repeated, x)
+			}
+		case *ast.ForStmt:
+			if containsPos(x.Body, pos) {
+				repeated = append(repeated, x.Body)
+				if x.Post != nil {
+					repeated = append(repeated, x.Post)
+				}
+			}
+		case *ast.RangeStmt:
+			if containsPos(x.Body, pos) {
+				repeated = append(repeated, x)
+			}
+		}
+		return true
+	})
+	after := func(n ast.Node) bool {
+		if n.Pos() > pos {
+			return true
+		}
+		for _, loop := range repeated {
+			if containsNode(loop, n) {
+				return true
+			}
+		}
+		return false
+	}
+
+	reassigned := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if reassigned {
+			return false
+		}
+		switch x := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range x.Lhs {
+				if after(lhs) && refersTo(info, lhs, obj) {
+					reassigned = true
+				}
+			}
+		case *ast.RangeStmt:
+			if x.Tok == token.ASSIGN && after(x) &&
+				(refersTo(info, x.Key, obj) || refersTo(info, x.Value, obj)) {
+				reassigned = true
+			}
+		case *ast.UnaryExpr:
+			if x.Op == token.AND && refersTo(info, x.X, obj) {
+				reassigned = true
+			}
+		}
+		return true
+	})
+	return reassigned
+}
+
+// refersTo reports whether e is (a possibly parenthesized) identifier
+// denoting obj.
+func refersTo(info *types.Info, e ast.Expr, obj types.Object) bool {
+	if e == nil {
+		return false
+	}
+	id := BaseIdentOf(e)
+	return id != nil && info.ObjectOf(id) == obj
+}
+
+// identsRefer reports whether any of ids denotes obj.
+func identsRefer(info *types.Info, ids []*ast.Ident, obj types.Object) bool {
+	for _, id := range ids {
+		if info.ObjectOf(id) == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPos reports whether pos lies within the source extent of n.
+func containsPos(n ast.Node, pos token.Pos) bool {
+	return n != nil && n.Pos() <= pos && pos < n.End()
+}
+
+// containsNode reports whether inner lies within the source extent of outer.
+func containsNode(outer, inner ast.Node) bool {
+	return outer != nil && outer.Pos() <= inner.Pos() && inner.End() <= outer.End()
+}
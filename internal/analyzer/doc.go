@@ -19,11 +19,25 @@
 // Parenthesized forms such as (*p).Field and (*p).Method() are conceptually
 // treated the same as the unparenthesized forms.
 //
+// "Pointer-typed" covers identifiers whose underlying type is a pointer
+// (including *E for a type parameter E) and type parameters whose core type
+// is a pointer, such as P in:
+//
+//	func F[E any, P interface{ *E; M() }](p P)
+//
+// Constraints without a core type (for example *A | *B) are not tracked.
+// Results of instantiated generic functions carry their concrete type and are
+// tracked like any other pointer.
+//
 // A "qualifying nil-check" (for v1) is any of:
 //
 //   - An if statement whose condition is `p != nil`.
 //   - An if statement whose condition is `p == nil` and whose "then" branch
-//     exits the function early via return or panic(...).
+//     exits the function early via return, panic(...), or a call that does
+//     not return, such as os.Exit, log.Fatal, runtime.Goexit or t.Fatal.
+//   - An if statement whose condition is `p == nil` and whose "then" branch
+//     repairs p by assigning it &x, new(T), or the result of a function that
+//     always returns a non-nil pointer.
 //
 // Examples of checks that DO count:
 //
@@ -39,36 +53,130 @@
 //	    panic("nil pointer")
 //	}
 //
+//	if p == nil {
+//	    p = new(T) // repaired; p is non-nil afterwards
+//	}
+//
 // Examples of checks that do NOT count:
 //
 //	if p == nil {
-//	    p = new(T) // no early exit; function continues
+//	    log.Print("p is nil") // neither exits nor repairs p
 //	}
 //
 //	if p == nil || someOtherCond {
 //	    // complex condition; out of scope for v1
 //	}
 //
+// # Near Misses
+//
+// An `if p == nil` check that neither exits nor repairs p, followed by a use
+// of p, is reported under the "near-miss" rule at the check itself: the nil
+// case was noticed but execution continues into the use.
+//
 // # Out of Scope for v1
 //
 // The following are intentionally out of scope for the initial implementation:
 //
 //   - Alias tracking: q := p; uses of q are not associated back to p.
-//   - Interprocedural reasoning: constructors like NewT() are not treated
-//     specially, even if they always return non-nil.
+//   - Interprocedural reasoning: beyond the returnsNonNil fact used for
+//     repair guards, constructors like NewT() are not treated specially;
+//     p := NewT() still needs a check before p is used.
 //   - Dominance / per-use flow: a single qualifying check anywhere in the
-//     function satisfies all uses of the pointer in that function.
+//     function satisfies all uses of the pointer in that function, unless a
+//     stricter profile is selected (see below).
 //   - Checks or uses inside nested function literals: a func literal is
-//     treated as its own function for nilguard's purposes.
+//     treated as its own function for nilguard's purposes, unless
+//     -closure-guards is enabled (see below).
+//
+// # Profiles
+//
+// The v1 policy above is the "lenient" profile, the default. The -profile
+// flag (Config.Profile) selects a stricter one:
+//
+//   - standard: each use must be dominated by a guard. A use inside the
+//     branch of a p != nil condition, or after an exiting or repairing
+//     if p == nil, is guarded until p is reassigned or its address is taken;
+//     branches are joined so that a guard must hold on every path, and loops
+//     drop guards that their body invalidates. Assigning &x, new(T) or the
+//     result of a returnsNonNil function guards p without a check.
+//   - strict: as standard, but pointers loaded from fields and elements
+//     (s.conn.Close(), m[k].X) need guards of their own, which end at a
+//     method call on, or a call passing, the root variable (s.reset(),
+//     f(s)), and comma-ok type assertions and type switches do not guard
+//     their result, which may be a typed nil pointer.
+//
+// The profile also sets the severity (error, warning or note) of each
+// Finding, which output formats such as SARIF render.
+//
+// # Use After Nil Assignment
+//
+// Independently of the per-function policy above, nilguard reports a
+// distinct diagnostic when a pointer variable or field path (p, s.conn) is
+// explicitly set to nil and then used on a control-flow path reachable from
+// that assignment:
+//
+//	if p != nil {
+//	    p.Close()
+//	    p = nil
+//	}
+//	p.Flush() // pointer "p" is used after being set to nil
+//
+// A path stops being tracked at a reassignment, at a condition that rules
+// out nil (if p != nil), or, for field paths, at a call that receives the
+// root variable. Deferred function literals run in reverse registration
+// order, so a deferred literal that reads s.conn is reported when a literal
+// deferred after it sets s.conn to nil.
+//
+// # Non-Nil Annotations
+//
+// A //nilguard:nonnil directive in a function's doc comment declares that
+// the named parameters or receiver are never nil, for example because every
+// caller checks them:
+//
+//	//nilguard:nonnil p // validated by every caller
+//	func apply(p *Patch) { p.Run() }
+//
+// Annotated pointers are trusted on entry to the function, under every
+// profile, until they are reassigned. Unlike a suppression, the annotation
+// documents a contract; its uses count as trusted in Result.Stats. The
+// directive is parsed like the suppression directives. An annotation outside
+// a function declaration's doc comment, or naming something other than a
+// parameter or the receiver, has no effect and is reported under the
+// bad-annotation rule.
+//
+// # Closure Guard Inheritance (opt-in)
+//
+// With the -closure-guards flag, a function literal inherits guards from its
+// enclosing functions for captured pointers. A captured pointer counts as
+// checked inside the literal when an enclosing function guards it before the
+// literal is created and never reassigns it (or takes its address) after that
+// guard, from earlier in a loop that contains the guard, or anywhere in
+// another function literal:
+//
+//	if p == nil {
+//	    return
+//	}
+//	g.Go(func() error {
+//	    return use(p.X) // OK with -closure-guards
+//	})
+//
+// Guards placed after the literal is created, or inside a block that does not
+// contain the literal, do not count.
 //
 // # Integrations
 //
 // The Analyzer type defined in this package is designed to be reused in
 // multiple frontends:
 //
-//   - Standalone CLI (via x/tools/go/analysis/singlechecker).
+//   - Standalone CLI (cmd/nilguard, a go/packages driver with text and SARIF
+//     output).
 //   - go vet tool (via x/tools/go/analysis/multichecker).
-//   - golangci-lint plugin (exported Analyzer symbol in a plugin package).
+//   - golangci-lint module plugin (plugin/golangci), or the legacy .so
+//     plugin (exported Analyzer symbol in a plugin package).
+//   - Bazel rules_go nogo (the nogo package), configured through the
+//     -exclude-files and -only-files flags.
+//   - Other Go programs, through the public pkg/nilguard package, which
+//     re-exports New, Config and the guard-recognition primitives.
 //
 // The Analyzer itself does not perform any I/O beyond reporting diagnostics
 // through analysis.Pass.
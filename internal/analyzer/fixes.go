@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// uncheckedFixes returns the suggested fixes for an unguarded use at pos of
+// the pointer spelled pointer, whose root variable is root: a nil guard
+// returning zero values before the statement containing the use, a
+// //nolint:nilguard directive, and for parameters and receivers a
+// //nilguard:nonnil annotation on the function. The guard comes first, so
+// that it is the fix applied by -fix.
+func uncheckedFixes(pass *analysis.Pass, fn funcContext, body *ast.BlockStmt, pos token.Pos, pointer string, root types.Object) []analysis.SuggestedFix {
+	stmt := stmtAround(body, pos)
+	if stmt == nil {
+		return nil
+	}
+	indent := indentOf(pass.Fset, stmt.Pos())
+
+	var fixes []analysis.SuggestedFix
+	// The guard must come after the declaration of the pointer, which may
+	// be the statement itself (if p := f(); p.X > 0).
+	if ret, ok := zeroReturn(pass, fn); ok && root.Pos() < stmt.Pos() {
+		fixes = append(fixes, analysis.SuggestedFix{
+			Message: fmt.Sprintf("Add nil guard for %s", pointer),
+			TextEdits: []analysis.TextEdit{{
+				Pos:     stmt.Pos(),
+				End:     stmt.Pos(),
+				NewText: fmt.Appendf(nil, "if %s == nil {\n%s\t%s\n%s}\n%s", pointer, indent, ret, indent, indent),
+			}},
+		})
+	}
+	fixes = append(fixes, analysis.SuggestedFix{
+		Message: "Suppress with //nolint:nilguard",
+		TextEdits: []analysis.TextEdit{{
+			Pos:     stmt.Pos(),
+			End:     stmt.Pos(),
+			NewText: fmt.Appendf(nil, "//nolint:nilguard // TODO: explain why %s cannot be nil\n%s", pointer, indent),
+		}},
+	})
+	if fd, ok := fn.node.(*ast.FuncDecl); ok && pointer == root.Name() && slices.Contains(params(pass.TypesInfo, fd), root) {
+		fixes = append(fixes, analysis.SuggestedFix{
+			Message: fmt.Sprintf("Annotate %s as non-nil", pointer),
+			TextEdits: []analysis.TextEdit{{
+				Pos:     fd.Pos(),
+				End:     fd.Pos(),
+				NewText: fmt.Appendf(nil, "//nilguard:nonnil %s // TODO: explain why %s is never nil\n", pointer, pointer),
+			}},
+		})
+	}
+	return fixes
+}
+
+// nearMissFixes returns the suggested fix for the near miss ifStmt: a return
+// of zero values at the end of its then-branch.
+func nearMissFixes(pass *analysis.Pass, fn funcContext, ifStmt *ast.IfStmt) []analysis.SuggestedFix {
+	ret, ok := zeroReturn(pass, fn)
+	if !ok {
+		return nil
+	}
+	b := ifStmt.Body
+	last := b.Lbrace
+	if n := len(b.List); n > 0 {
+		last = b.List[n-1].End()
+	}
+	if pass.Fset.Position(last).Line == pass.Fset.Position(b.Rbrace).Line {
+		return nil // no line of its own for the return
+	}
+	indent := indentOf(pass.Fset, b.Rbrace)
+	return []analysis.SuggestedFix{{
+		Message: "Return when " + types.ExprString(ifStmt.Cond),
+		TextEdits: []analysis.TextEdit{{
+			Pos:     b.Rbrace,
+			End:     b.Rbrace,
+			NewText: fmt.Appendf(nil, "\t%s\n%s", ret, indent),
+		}},
+	}}
+}
+
+// removeDirectiveFixes returns the suggested fix for the unused suppression
+// s: deleting the directive, and its line if it stands alone.
+func removeDirectiveFixes(pass *analysis.Pass, s *suppression) []analysis.SuggestedFix {
+	pos, end := s.comment.Pos(), s.comment.End()
+	if !s.trailing {
+		tf := pass.Fset.File(pos)
+		line := tf.Line(pos)
+		pos = tf.LineStart(line)
+		if line < tf.LineCount() {
+			end = tf.LineStart(line + 1)
+		}
+	}
+	return []analysis.SuggestedFix{{
+		Message:   "Remove unused " + s.directive.String(),
+		TextEdits: []analysis.TextEdit{{Pos: pos, End: end}},
+	}}
+}
+
+// stmtAround returns the innermost statement of a statement list in body
+// that contains pos, or nil. Nested function literals are not entered.
+func stmtAround(body *ast.BlockStmt, pos token.Pos) ast.Stmt {
+	var found ast.Stmt
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || pos >= n.End() {
+			return false
+		}
+		var list []ast.Stmt
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.BlockStmt:
+			list = x.List
+		case *ast.CaseClause:
+			list = x.Body
+		case *ast.CommClause:
+			list = x.Body
+		}
+		for _, s := range list {
+			if s.Pos() <= pos && pos < s.End() {
+				found = s
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// indentOf returns the indentation of gofmt-formatted code at pos: one tab
+// per column before it.
+func indentOf(fset *token.FileSet, pos token.Pos) string {
+	return strings.Repeat("\t", fset.Position(pos).Column-1)
+}
+
+// zeroReturn returns a return statement that leaves the function fn with
+// zero results: a bare return for no or named results, and otherwise zero
+// values. ok is false if some result type has no zero value that can be
+// spelled in fn's package.
+func zeroReturn(pass *analysis.Pass, fn funcContext) (string, bool) {
+	sig := funcSignature(pass.TypesInfo, fn.node)
+	if sig == nil {
+		return "", false
+	}
+	res := sig.Results()
+	if res.Len() == 0 || res.At(0).Name() != "" {
+		return "return", true
+	}
+	vals := make([]string, res.Len())
+	for i := range vals {
+		z, ok := zeroValue(pass.Pkg, res.At(i).Type())
+		if !ok {
+			return "", false
+		}
+		vals[i] = z
+	}
+	return "return " + strings.Join(vals, ", "), true
+}
+
+// zeroValue returns the Go spelling of the zero value of t within pkg.
+func zeroValue(pkg *types.Package, t types.Type) (string, bool) {
+	if tp, ok := t.(*types.TypeParam); ok {
+		return "*new(" + tp.Obj().Name() + ")", true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", true
+	case *types.Struct, *types.Array:
+		// Composite literals need the type name, which is only known to be
+		// in scope for types of pkg itself.
+		if named, ok := t.(*types.Named); ok && named.Obj().Pkg() == pkg {
+			return types.TypeString(t, types.RelativeTo(pkg)) + "{}", true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,573 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// guard records why a pointer is known to be non-nil.
+type guard uint8
+
+const (
+	// guardCheck is a nil check, or a guard inherited from the enclosing
+	// function.
+	guardCheck guard = iota + 1
+
+	// guardTrust is a non-nil assignment (&x, new(T), trusted constructors)
+	// or a type assertion or type switch binding, trusted without a check.
+	guardTrust
+)
+
+// guardSet maps the pointers known to be non-nil at a program point to the
+// reason they are.
+type guardSet map[nilTarget]guard
+
+// clone returns a copy of g.
+func (g guardSet) clone() guardSet {
+	c := make(guardSet, len(g))
+	for t, k := range g {
+		c[t] = k
+	}
+	return c
+}
+
+// with returns a copy of g extended with ts, which are guarded by a check.
+func (g guardSet) with(ts []nilTarget) guardSet {
+	c := g.clone()
+	for _, t := range ts {
+		c[t] = guardCheck
+	}
+	return c
+}
+
+// kill removes every target replaced by an assignment to t.
+func (g guardSet) kill(t nilTarget) {
+	for u := range g {
+		if u.covers(t) {
+			delete(g, u)
+		}
+	}
+}
+
+// intersect returns the targets present in every set of gs, or nil if gs is
+// empty. A target is only trusted if it is trusted in every set.
+func intersect(gs []guardSet) guardSet {
+	if len(gs) == 0 {
+		return nil
+	}
+	out := gs[0].clone()
+	for _, g := range gs[1:] {
+		for t, k := range out {
+			switch {
+			case g[t] == 0:
+				delete(out, t)
+			case g[t] < k:
+				out[t] = g[t]
+			}
+		}
+	}
+	return out
+}
+
+// flowChecker finds the pointer uses of a function body that are not
+// dominated by a guard, for the standard and strict profiles.
+//
+// It walks the statements in order while tracking the guardSet: p != nil
+// conditions guard their then-branch (and p == nil conditions their else
+// branch), an exiting or repairing if p == nil guards the statements that
+// follow it, and non-nil assignments (&x, new(T), trusted constructors)
+// guard their target until it is reassigned. Branches are joined by
+// intersection; loops drop any guard that their body may invalidate.
+type flowChecker struct {
+	pass   *analysis.Pass
+	strict bool
+
+	// unguarded lists, per target, the uses not dominated by a guard.
+	unguarded map[nilTarget][]token.Pos
+
+	// exprs records the expression of each strict (field or element)
+	// target, for its type in diagnostics.
+	exprs map[nilTarget]ast.Expr
+
+	// trusted lists, per variable, the uses guarded only by trust.
+	trusted map[nilTarget][]token.Pos
+}
+
+// check walks body starting from the guards in entry and the trusted
+// pointers in trusted, and returns the unguarded uses of every target.
+func (fc *flowChecker) check(body *ast.BlockStmt, entry, trusted map[types.Object]bool) map[nilTarget][]token.Pos {
+	fc.unguarded = make(map[nilTarget][]token.Pos)
+	fc.exprs = make(map[nilTarget]ast.Expr)
+	fc.trusted = make(map[nilTarget][]token.Pos)
+	g := make(guardSet)
+	for obj := range entry {
+		g[nilTarget{root: obj}] = guardCheck
+	}
+	for obj := range trusted {
+		g[nilTarget{root: obj}] = guardTrust
+	}
+	fc.block(body.List, g)
+	return fc.unguarded
+}
+
+// block processes stmts in order and returns the guards at their end.
+func (fc *flowChecker) block(stmts []ast.Stmt, g guardSet) guardSet {
+	for _, s := range stmts {
+		g = fc.stmt(s, g)
+	}
+	return g
+}
+
+// stmt processes s with the guards g, which it may modify, and returns the
+// guards after s.
+func (fc *flowChecker) stmt(s ast.Stmt, g guardSet) guardSet {
+	info := fc.pass.TypesInfo
+
+	switch s := s.(type) {
+	case nil:
+		return g
+
+	case *ast.BlockStmt:
+		return fc.block(s.List, g)
+
+	case *ast.LabeledStmt:
+		return fc.stmt(s.Stmt, g)
+
+	case *ast.AssignStmt:
+		for _, e := range s.Rhs {
+			fc.expr(e, g)
+		}
+		for _, e := range s.Lhs {
+			if _, ok := ast.Unparen(e).(*ast.Ident); !ok {
+				fc.expr(e, g)
+			}
+		}
+		fc.killAddressTaken(s, g)
+		fc.killCalled(s, g)
+		if s.Tok != token.ASSIGN && s.Tok != token.DEFINE {
+			return g // op-assignments do not produce pointers
+		}
+		for i, lhs := range s.Lhs {
+			t, ok := fc.target(lhs)
+			if !ok {
+				continue
+			}
+			g.kill(t)
+			switch {
+			case len(s.Rhs) == len(s.Lhs) && isNonNilExpr(fc.pass, nil, s.Rhs[i]):
+				g[t] = guardTrust
+			case i == 0 && len(s.Lhs) == 2 && len(s.Rhs) == 1 && !fc.strict:
+				// v, ok := x.(*T): trusted like the lenient profile does,
+				// except in strict mode where v may be a typed nil.
+				if _, ok := ast.Unparen(s.Rhs[0]).(*ast.TypeAssertExpr); ok {
+					g[t] = guardTrust
+				}
+			}
+		}
+		return g
+
+	case *ast.DeclStmt:
+		gd, ok := s.Decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			return g
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for _, e := range vs.Values {
+				fc.expr(e, g)
+				fc.killCalled(e, g)
+			}
+			for i, id := range vs.Names {
+				obj := info.Defs[id]
+				if obj == nil {
+					continue
+				}
+				if len(vs.Values) == len(vs.Names) && isNonNilExpr(fc.pass, nil, vs.Values[i]) {
+					g[nilTarget{root: obj}] = guardTrust
+				}
+			}
+		}
+		return g
+
+	case *ast.IfStmt:
+		g = fc.stmt(s.Init, g)
+		fc.expr(s.Cond, g)
+		fc.killCalled(s.Cond, g)
+		whenTrue := g.with(fc.targets(NonNilWhenTrueExprs(info, s.Cond)))
+		whenFalse := g.with(fc.targets(NonNilWhenFalseExprs(info, s.Cond)))
+
+		var outs []guardSet
+		if out := fc.block(s.Body.List, whenTrue); !ExitsEarly(info, s.Body) {
+			outs = append(outs, out)
+		}
+		switch e := s.Else.(type) {
+		case nil:
+			outs = append(outs, whenFalse)
+		case *ast.BlockStmt:
+			if out := fc.block(e.List, whenFalse); !ExitsEarly(info, e) {
+				outs = append(outs, out)
+			}
+		default:
+			outs = append(outs, fc.stmt(e, whenFalse))
+		}
+		if len(outs) == 0 {
+			// Both branches exit; what follows is unreachable from here.
+			return g
+		}
+		return intersect(outs)
+
+	case *ast.ForStmt:
+		g = fc.stmt(s.Init, g)
+		fc.killAssigned(s.Body, g)
+		fc.killAssigned(s.Post, g)
+		fc.expr(s.Cond, g)
+		fc.block(s.Body.List, g.with(fc.targets(NonNilWhenTrueExprs(info, s.Cond))))
+		fc.stmt(s.Post, g.clone())
+		return g
+
+	case *ast.RangeStmt:
+		fc.expr(s.X, g)
+		fc.killCalled(s.X, g)
+		fc.killAssigned(s, g)
+		fc.block(s.Body.List, g.clone())
+		return g
+
+	case *ast.SwitchStmt:
+		g = fc.stmt(s.Init, g)
+		fc.expr(s.Tag, g)
+		fc.killCalled(s.Tag, g)
+		return fc.clauses(s.Body, g, nil)
+
+	case *ast.TypeSwitchStmt:
+		g = fc.stmt(s.Init, g)
+		fc.stmt(s.Assign, g)
+		return fc.clauses(s.Body, g, func(cc *ast.CaseClause, cg guardSet) {
+			// Each clause binds its own implicit variable. A single pointer
+			// type case is trusted, except in strict mode (typed nils).
+			if obj := info.Implicits[cc]; obj != nil && !fc.strict && len(cc.List) == 1 {
+				cg[nilTarget{root: obj}] = guardTrust
+			}
+		})
+
+	case *ast.SelectStmt:
+		fc.killAssigned(s, g)
+		for _, c := range s.Body.List {
+			cc := c.(*ast.CommClause)
+			cg := fc.stmt(cc.Comm, g.clone())
+			fc.block(cc.Body, cg)
+		}
+		return g
+
+	default:
+		// Expression, send, inc/dec, go, defer, return and branch
+		// statements: check the uses they contain.
+		ast.Inspect(s, func(n ast.Node) bool {
+			if e, ok := n.(ast.Expr); ok {
+				fc.expr(e, g)
+				return false
+			}
+			return true
+		})
+		fc.killAddressTaken(s, g)
+		fc.killCalled(s, g)
+		return g
+	}
+}
+
+// clauses processes the case clauses of a switch or type switch, calling
+// bind (if non-nil) to add per-clause guards, and returns the join of the
+// guards after every clause that does not exit.
+func (fc *flowChecker) clauses(body *ast.BlockStmt, g guardSet, bind func(*ast.CaseClause, guardSet)) guardSet {
+	var outs []guardSet
+	hasDefault := false
+	for _, c := range body.List {
+		cc := c.(*ast.CaseClause)
+		if cc.List == nil {
+			hasDefault = true
+		}
+		for _, e := range cc.List {
+			fc.expr(e, g)
+		}
+		cg := g.clone()
+		if bind != nil {
+			bind(cc, cg)
+		}
+		out := fc.block(cc.Body, cg)
+		if !ExitsEarly(fc.pass.TypesInfo, &ast.BlockStmt{List: cc.Body}) {
+			outs = append(outs, out)
+		}
+	}
+	if !hasDefault {
+		outs = append(outs, g)
+	}
+	if len(outs) == 0 {
+		return g
+	}
+	return intersect(outs)
+}
+
+// expr checks the pointer uses in e against the guards g. The right operand
+// of && and || is checked with the guards implied by the left operand.
+func (fc *flowChecker) expr(e ast.Expr, g guardSet) {
+	if e == nil {
+		return
+	}
+	info := fc.pass.TypesInfo
+	ast.Inspect(e, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.BinaryExpr:
+			switch x.Op {
+			case token.LAND:
+				fc.expr(x.X, g)
+				fc.expr(x.Y, g.with(fc.targets(NonNilWhenTrueExprs(info, x.X))))
+				return false
+			case token.LOR:
+				fc.expr(x.X, g)
+				fc.expr(x.Y, g.with(fc.targets(NonNilWhenFalseExprs(info, x.X))))
+				return false
+			}
+
+		case *ast.StarExpr:
+			fc.use(x.X, x.Pos(), g)
+
+		case *ast.SelectorExpr:
+			fc.use(x.X, x.Pos(), g)
+		}
+		return true
+	})
+}
+
+// use records a use at pos of the pointer expression base (the operand of
+// a dereference or selector) if it is not guarded, or if it is a variable
+// guarded only by trust.
+func (fc *flowChecker) use(base ast.Expr, pos token.Pos, g guardSet) {
+	info := fc.pass.TypesInfo
+	if id := BaseIdentOf(base); id != nil {
+		if !isPointerIdent(info, id) {
+			return
+		}
+		obj := info.ObjectOf(id)
+		if obj == nil {
+			return
+		}
+		switch t := (nilTarget{root: obj}); g[t] {
+		case 0:
+			fc.unguarded[t] = append(fc.unguarded[t], pos)
+		case guardTrust:
+			fc.trusted[t] = append(fc.trusted[t], pos)
+		}
+		return
+	}
+	if !fc.strict || !isPointerExpr(info, base) {
+		return
+	}
+	t, ok := fc.target(base)
+	if !ok || t.path == "" || g[t] != 0 {
+		return
+	}
+	if _, seen := fc.exprs[t]; !seen {
+		fc.exprs[t] = base
+	}
+	fc.unguarded[t] = append(fc.unguarded[t], pos)
+}
+
+// target returns the guard target denoted by e: a variable, or in strict
+// mode also a field path or element expression rooted at a variable.
+func (fc *flowChecker) target(e ast.Expr) (nilTarget, bool) {
+	if !fc.strict {
+		if id, ok := ast.Unparen(e).(*ast.Ident); ok {
+			if obj, ok := fc.pass.TypesInfo.ObjectOf(id).(*types.Var); ok {
+				return nilTarget{root: obj}, true
+			}
+		}
+		return nilTarget{}, false
+	}
+	return elementTargetOf(fc.pass.TypesInfo, e)
+}
+
+// targets returns the guard targets of es, skipping those it cannot track.
+func (fc *flowChecker) targets(es []ast.Expr) []nilTarget {
+	var ts []nilTarget
+	for _, e := range es {
+		if t, ok := fc.target(e); ok {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// killAssigned removes from g every target that n may assign or whose
+// address n takes, and the field and element targets that a call in n may
+// reset (see killCalled), outside nested function literals.
+func (fc *flowChecker) killAssigned(n ast.Node, g guardSet) {
+	if n == nil {
+		return
+	}
+	fc.killCalled(n, g)
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			for _, lhs := range x.Lhs {
+				if t, ok := fc.target(lhs); ok {
+					g.kill(t)
+				}
+			}
+		case *ast.RangeStmt:
+			for _, e := range []ast.Expr{x.Key, x.Value} {
+				if t, ok := fc.target(e); ok {
+					g.kill(t)
+				}
+			}
+		case *ast.UnaryExpr:
+			if x.Op == token.AND {
+				if t, ok := fc.target(x.X); ok {
+					g.kill(t)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// killAddressTaken removes from g every target whose address s takes: the
+// pointee may be reassigned through the resulting pointer.
+func (fc *flowChecker) killAddressTaken(s ast.Node, g guardSet) {
+	ast.Inspect(s, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.UnaryExpr:
+			if x.Op == token.AND {
+				if t, ok := fc.target(x.X); ok {
+					g.kill(t)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// killCalled removes from g the field and element targets rooted at a
+// variable that a call in n receives, as the receiver of a method call or as
+// an argument: the callee may reset the field, as in
+//
+//	if s.conn == nil {
+//	    return
+//	}
+//	s.reset()
+//	s.conn.Read(buf) // s.conn is no longer guarded
+//
+// Only strict targets have paths, so this is a no-op for other profiles.
+func (fc *flowChecker) killCalled(n ast.Node, g guardSet) {
+	if !fc.strict || n == nil {
+		return
+	}
+	info := fc.pass.TypesInfo
+	killRoot := func(e ast.Expr) {
+		id := BaseIdentOf(e)
+		if id == nil {
+			return
+		}
+		obj := info.ObjectOf(id)
+		for t := range g {
+			if t.root == obj && t.path != "" {
+				delete(g, t)
+			}
+		}
+	}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if sel, ok := ast.Unparen(x.Fun).(*ast.SelectorExpr); ok {
+				killRoot(sel.X)
+			}
+			for _, arg := range x.Args {
+				killRoot(arg)
+			}
+		}
+		return true
+	})
+}
+
+// elementTargetOf is like targetOf, but also accepts index expressions on
+// maps, slices and arrays whose index is an identifier or a literal, e.g.
+// m[k] or s.items[0].next.
+func elementTargetOf(info *types.Info, e ast.Expr) (nilTarget, bool) {
+	switch x := ast.Unparen(e).(type) {
+	case *ast.IndexExpr:
+		switch info.TypeOf(x.X).Underlying().(type) {
+		case *types.Map, *types.Slice, *types.Array:
+		default:
+			return nilTarget{}, false
+		}
+		switch x.Index.(type) {
+		case *ast.Ident, *ast.BasicLit:
+		default:
+			return nilTarget{}, false
+		}
+		base, ok := elementTargetOf(info, x.X)
+		if !ok {
+			return nilTarget{}, false
+		}
+		base.path += "[" + types.ExprString(x.Index) + "]"
+		return base, true
+	case *ast.SelectorExpr:
+		sel, ok := info.Selections[x]
+		if !ok || sel.Kind() != types.FieldVal {
+			return nilTarget{}, false
+		}
+		base, ok := elementTargetOf(info, x.X)
+		if !ok {
+			return nilTarget{}, false
+		}
+		base.path += "." + x.Sel.Name
+		return base, true
+	}
+	return targetOf(info, e)
+}
+
+// NonNilWhenTrueExprs is like NonNilWhenTrue, but returns every pointer
+// expression compared against nil, including fields and elements such as
+// s.conn or m[k].
+func NonNilWhenTrueExprs(info *types.Info, e ast.Expr) []ast.Expr {
+	return nilComparedExprs(info, e, token.LAND, token.NEQ)
+}
+
+// NonNilWhenFalseExprs is like NonNilWhenFalse, but returns every pointer
+// expression compared against nil, including fields and elements.
+func NonNilWhenFalseExprs(info *types.Info, e ast.Expr) []ast.Expr {
+	return nilComparedExprs(info, e, token.LOR, token.EQL)
+}
+
+// nilComparedExprs returns the pointer expressions x in the chain of join
+// operators e that appear in comparisons x op nil or nil op x.
+func nilComparedExprs(info *types.Info, e ast.Expr, join, op token.Token) []ast.Expr {
+	switch x := e.(type) {
+	case *ast.ParenExpr:
+		return nilComparedExprs(info, x.X, join, op)
+	case *ast.BinaryExpr:
+		if x.Op == join {
+			return append(nilComparedExprs(info, x.X, join, op), nilComparedExprs(info, x.Y, join, op)...)
+		}
+		if x.Op != op {
+			return nil
+		}
+		switch {
+		case isNil(x.Y) && isPointerExpr(info, x.X):
+			return []ast.Expr{x.X}
+		case isNil(x.X) && isPointerExpr(info, x.Y):
+			return []ast.Expr{x.Y}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/cfg"
+)
+
+// nilTarget identifies a pointer-valued variable or field path that can be
+// explicitly set to nil, such as p or s.conn.
+type nilTarget struct {
+	// root is the variable at the base of the path.
+	root types.Object
+
+	// path holds the field selections (and, for the strict profile, the
+	// indexing) applied to root, e.g. ".conn" or "[k].next". It is empty for
+	// a plain variable.
+	path string
+}
+
+// name returns the source-like spelling of t used in diagnostics.
+func (t nilTarget) name() string {
+	return t.root.Name() + t.path
+}
+
+// covers reports whether assigning u also replaces the value of t, i.e. u is
+// t itself or a prefix of t's path.
+func (t nilTarget) covers(u nilTarget) bool {
+	return t.root == u.root && (t.path == u.path ||
+		strings.HasPrefix(t.path, u.path+".") || strings.HasPrefix(t.path, u.path+"["))
+}
+
+// nilAssignment records an explicit `t = nil` within a function body.
+type nilAssignment struct {
+	target nilTarget
+	pos    token.Pos
+	node   ast.Node // the AssignStmt or ValueSpec as it appears in the CFG
+}
+
+// checkNilAssignments reports uses of pointers that were explicitly set to
+// nil earlier on some control-flow path of body:
+//
+//	if p != nil {
+//	    p.Close()
+//	    p = nil
+//	}
+//	p.Flush() // used after being set to nil
+//
+// It also reports deferred function literals that use a pointer which a
+// deferred literal registered later (and therefore run earlier) sets to nil.
+//
+// This check is independent of the per-function nil-check policy enforced by
+// checkFunc: a qualifying check elsewhere in the function does not satisfy it.
+func checkNilAssignments(st *passState, fn funcContext, body *ast.BlockStmt) {
+	info := st.pass.TypesInfo
+
+	reported := make(map[token.Pos]bool)
+	report := func(use token.Pos, a nilAssignment) {
+		if reported[use] {
+			return
+		}
+		reported[use] = true
+		st.report(fn, a.target.name(), analysis.Diagnostic{
+			Pos:      use,
+			Category: RuleNilAssign,
+			Message:  fmt.Sprintf("pointer %q is used after being set to nil", a.target.name()),
+			Related: []analysis.RelatedInformation{{
+				Pos:     a.pos,
+				Message: "set to nil here",
+			}},
+		})
+	}
+
+	// Straight-line and branching code within this body.
+	assigns := collectNilAssignments(info, body)
+	var g *cfg.CFG
+	if len(assigns) > 0 {
+		g = cfg.New(body, func(call *ast.CallExpr) bool { return callMayReturn(info, call) })
+	}
+	for _, a := range assigns {
+		if capturedAndAssigned(info, body, a.target) {
+			continue
+		}
+		b, idx := findCFGNode(g, a.node)
+		if b == nil {
+			continue
+		}
+		for _, use := range nilPathUses(info, b, idx+1, a.target) {
+			report(use, a)
+		}
+	}
+
+	// Deferred literals run in reverse registration order, so a literal
+	// deferred earlier observes nil assignments made by one deferred later.
+	defers := deferredLits(body)
+	for i, later := range defers {
+		for _, a := range collectNilAssignments(info, later.Body) {
+			for _, earlier := range defers[:i] {
+				lg := cfg.New(earlier.Body, func(call *ast.CallExpr) bool { return callMayReturn(info, call) })
+				if len(lg.Blocks) == 0 {
+					continue
+				}
+				for _, use := range nilPathUses(info, lg.Blocks[0], 0, a.target) {
+					report(use, a)
+				}
+			}
+		}
+	}
+}
+
+// collectNilAssignments returns the explicit nil assignments to pointer
+// variables and fields in body, excluding those inside nested function
+// literals:
+//
+//	p = nil
+//	s.conn = nil
+//	var p *T = nil
+func collectNilAssignments(info *types.Info, body *ast.BlockStmt) []nilAssignment {
+	var out []nilAssignment
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.AssignStmt:
+			if x.Tok != token.ASSIGN || len(x.Lhs) != len(x.Rhs) {
+				return true
+			}
+			for i, rhs := range x.Rhs {
+				if !isNil(rhs) {
+					continue
+				}
+				if t, ok := targetOf(info, x.Lhs[i]); ok && isPointerExpr(info, x.Lhs[i]) {
+					out = append(out, nilAssignment{target: t, pos: x.Lhs[i].Pos(), node: x})
+				}
+			}
+
+		case *ast.ValueSpec:
+			if len(x.Names) != len(x.Values) {
+				return true
+			}
+			for i, v := range x.Values {
+				if !isNil(v) || !isPointerIdent(info, x.Names[i]) {
+					continue
+				}
+				if obj := info.ObjectOf(x.Names[i]); obj != nil {
+					out = append(out, nilAssignment{target: nilTarget{root: obj}, pos: x.Names[i].Pos(), node: x})
+				}
+			}
+		}
+		return true
+	})
+	return out
+}
+
+// targetOf returns the nilTarget denoted by e, which must be an identifier or
+// a chain of field selections rooted at an identifier (possibly wrapped in
+// parentheses).
+func targetOf(info *types.Info, e ast.Expr) (nilTarget, bool) {
+	switch x := e.(type) {
+	case *ast.ParenExpr:
+		return targetOf(info, x.X)
+	case *ast.Ident:
+		obj := info.ObjectOf(x)
+		if _, ok := obj.(*types.Var); !ok {
+			return nilTarget{}, false
+		}
+		return nilTarget{root: obj}, true
+	case *ast.SelectorExpr:
+		sel, ok := info.Selections[x]
+		if !ok || sel.Kind() != types.FieldVal {
+			return nilTarget{}, false
+		}
+		base, ok := targetOf(info, x.X)
+		if !ok {
+			return nilTarget{}, false
+		}
+		base.path += "." + x.Sel.Name
+		return base, true
+	}
+	return nilTarget{}, false
+}
+
+// isPointerExpr reports whether e has a pointer type (see IsPointerType).
+func isPointerExpr(info *types.Info, e ast.Expr) bool {
+	return IsPointerType(info.TypeOf(e))
+}
+
+// findCFGNode returns the block containing n and n's index within it.
+func findCFGNode(g *cfg.CFG, n ast.Node) (*cfg.Block, int) {
+	for _, b := range g.Blocks {
+		for i, bn := range b.Nodes {
+			if bn == n {
+				return b, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+// nilPathUses walks every control-flow path starting at node index from in
+// block start, on which t is known to be nil, and returns the first use of t
+// on each path. A path ends at the first use, at any statement that may
+// replace t, or when a condition rules out t being nil.
+func nilPathUses(info *types.Info, start *cfg.Block, from int, t nilTarget) []token.Pos {
+	var uses []token.Pos
+	visited := make(map[*cfg.Block]bool)
+
+	var walk func(b *cfg.Block, from int)
+	walk = func(b *cfg.Block, from int) {
+		for i := from; i < len(b.Nodes); i++ {
+			n := b.Nodes[i]
+			if pos := firstNilUse(info, n, t); pos.IsValid() {
+				uses = append(uses, pos)
+				return
+			}
+			if mayReplace(info, n, t) {
+				return
+			}
+		}
+
+		succs := b.Succs
+		if len(succs) == 2 && len(b.Nodes) > 0 {
+			if cond, ok := b.Nodes[len(b.Nodes)-1].(ast.Expr); ok {
+				switch {
+				case guardsTarget(info, cond, t, token.NEQ, token.LAND):
+					// cond implies t != nil: the true branch is infeasible.
+					succs = succs[1:]
+				case guardsTarget(info, cond, t, token.EQL, token.LOR):
+					// !cond implies t != nil: the false branch is infeasible.
+					succs = succs[:1]
+				}
+			}
+		}
+		for _, s := range succs {
+			if !visited[s] {
+				visited[s] = true
+				walk(s, 0)
+			}
+		}
+	}
+	walk(start, from)
+
+	return uses
+}
+
+// guardsTarget reports whether e contains a comparison `t <op> nil` (or
+// `nil <op> t`) reachable through a chain of join operators. With op NEQ and
+// join LAND it reports whether e being true implies t != nil; with op EQL and
+// join LOR it reports whether e being false implies t != nil.
+func guardsTarget(info *types.Info, e ast.Expr, t nilTarget, op, join token.Token) bool {
+	switch x := e.(type) {
+	case *ast.ParenExpr:
+		return guardsTarget(info, x.X, t, op, join)
+	case *ast.BinaryExpr:
+		if x.Op == join {
+			return guardsTarget(info, x.X, t, op, join) || guardsTarget(info, x.Y, t, op, join)
+		}
+		if x.Op != op {
+			return false
+		}
+		if isNil(x.Y) {
+			u, ok := targetOf(info, x.X)
+			return ok && u == t
+		}
+		if isNil(x.X) {
+			u, ok := targetOf(info, x.Y)
+			return ok && u == t
+		}
+	}
+	return false
+}
+
+// firstNilUse returns the position of the first use of t within n, honoring
+// the short-circuit semantics of && and || so that `t != nil && t.X` is not
+// treated as a use. Nested function literals are not inspected.
+func firstNilUse(info *types.Info, n ast.Node, t nilTarget) token.Pos {
+	pos := token.NoPos
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		if pos.IsValid() {
+			return false
+		}
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.BinaryExpr:
+			if x.Op == token.LAND && guardsTarget(info, x.X, t, token.NEQ, token.LAND) ||
+				x.Op == token.LOR && guardsTarget(info, x.X, t, token.EQL, token.LOR) {
+				// The right operand is only evaluated when t != nil.
+				ast.Inspect(x.X, visit)
+				return false
+			}
+
+		case *ast.SelectorExpr:
+			if u, ok := targetOf(info, x.X); ok && u == t {
+				pos = x.Pos()
+				return false
+			}
+
+		case *ast.StarExpr:
+			if u, ok := targetOf(info, x.X); ok && u == t {
+				pos = x.Pos()
+				return false
+			}
+		}
+		return true
+	}
+	ast.Inspect(n, visit)
+	return pos
+}
+
+// mayReplace reports whether evaluating n may give t a new value: an
+// assignment to t or to a prefix of its path, taking the address of such a
+// prefix, or (for field paths) a call that receives the root variable.
+func mayReplace(info *types.Info, n ast.Node, t nilTarget) bool {
+	// Bare expressions in the CFG are range keys/values and select receive
+	// targets, all of which are assigned.
+	if e, ok := n.(ast.Expr); ok {
+		if u, ok := targetOf(info, e); ok && t.covers(u) {
+			return true
+		}
+	}
+
+	replaced := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if replaced {
+			return false
+		}
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.AssignStmt:
+			for _, lhs := range x.Lhs {
+				if u, ok := targetOf(info, lhs); ok && t.covers(u) {
+					replaced = true
+				}
+			}
+
+		case *ast.UnaryExpr:
+			if u, ok := targetOf(info, x.X); x.Op == token.AND && ok && t.covers(u) {
+				replaced = true
+			}
+
+		case *ast.CallExpr:
+			if t.path == "" {
+				return true
+			}
+			// A method call on, or call passing, the root may reset the field.
+			if sel, ok := x.Fun.(*ast.SelectorExpr); ok && refersTo(info, sel.X, t.root) {
+				replaced = true
+			}
+			for _, arg := range x.Args {
+				if refersTo(info, arg, t.root) {
+					replaced = true
+				}
+			}
+		}
+		return true
+	})
+	return replaced
+}
+
+// capturedAndAssigned reports whether a function literal within body assigns
+// to t or a prefix of it. Such targets are skipped, since the literal may run
+// between the nil assignment and a later use.
+func capturedAndAssigned(info *types.Info, body *ast.BlockStmt, t nilTarget) bool {
+	assigned := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		lit, ok := n.(*ast.FuncLit)
+		if !ok || assigned {
+			return !assigned
+		}
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			if as, ok := n.(*ast.AssignStmt); ok {
+				for _, lhs := range as.Lhs {
+					if u, ok := targetOf(info, lhs); ok && t.covers(u) {
+						assigned = true
+					}
+				}
+			}
+			return !assigned
+		})
+		return false
+	})
+	return assigned
+}
+
+// deferredLits returns the function literals deferred directly by body, in
+// source (registration) order.
+func deferredLits(body *ast.BlockStmt) []*ast.FuncLit {
+	var lits []*ast.FuncLit
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.DeferStmt:
+			if lit, ok := x.Call.Fun.(*ast.FuncLit); ok {
+				lits = append(lits, lit)
+			}
+			return false
+		}
+		return true
+	})
+	return lits
+}
+
+// callMayReturn reports whether call may return normally. It is used to end
+// CFG paths at panic, os.Exit, log.Fatal*, runtime.Goexit and the testing
+// methods that stop a test, such as t.Fatal and t.Skip.
+func callMayReturn(info *types.Info, call *ast.CallExpr) bool {
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		if b, ok := info.Uses[fn].(*types.Builtin); ok && b.Name() == "panic" {
+			return false
+		}
+	case *ast.SelectorExpr:
+		obj, ok := info.Uses[fn.Sel].(*types.Func)
+		if !ok || obj.Pkg() == nil {
+			return true
+		}
+		if recv := obj.Signature().Recv(); recv != nil {
+			// Methods of testing.T, B, F and TB, most of them promoted
+			// from the unexported testing.common.
+			if obj.Pkg().Path() != "testing" {
+				return true
+			}
+			switch obj.Name() {
+			case "Fatal", "Fatalf", "FailNow", "Skip", "Skipf", "SkipNow":
+				return false
+			}
+			return true
+		}
+		switch obj.Pkg().Path() + "." + obj.Name() {
+		case "os.Exit", "log.Fatal", "log.Fatalf", "log.Fatalln", "log.Panic", "log.Panicf", "log.Panicln",
+			"runtime.Goexit":
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,286 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// returnsNonNil is an object fact attached to functions whose first result
+// is a pointer that is never nil: every return statement returns an
+// address-of expression, new(T), or the result of another such function.
+// Calls to these functions count as non-nil values when repairing a pointer
+// (see repairs).
+type returnsNonNil struct{}
+
+func (*returnsNonNil) AFact() {}
+
+func (*returnsNonNil) String() string { return "returnsNonNil" }
+
+// exportNonNilFacts computes returnsNonNil for the functions declared in the
+// package and exports it. Functions that return the result of other
+// functions in the same package are resolved by iterating to a fixed point.
+func exportNonNilFacts(pass *analysis.Pass) {
+	type candidate struct {
+		fn      *types.Func
+		results []ast.Expr // first result of every return statement
+	}
+	var cands []candidate
+
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			res := fn.Type().(*types.Signature).Results()
+			if res.Len() == 0 || !IsPointerType(res.At(0).Type()) {
+				continue
+			}
+			results, ok := firstResults(fd.Body)
+			if ok && len(results) > 0 {
+				cands = append(cands, candidate{fn, results})
+			}
+		}
+	}
+
+	nonNil := make(map[*types.Func]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, c := range cands {
+			if nonNil[c.fn] {
+				continue
+			}
+			all := true
+			for _, e := range c.results {
+				if !isNonNilExpr(pass, nonNil, e) {
+					all = false
+					break
+				}
+			}
+			if all {
+				nonNil[c.fn] = true
+				changed = true
+			}
+		}
+	}
+
+	for fn := range nonNil {
+		pass.ExportObjectFact(fn, new(returnsNonNil))
+	}
+}
+
+// firstResults returns the first result expression of every return statement
+// in body, excluding nested function literals. ok is false if some return
+// statement has no results (a bare return of named results).
+func firstResults(body *ast.BlockStmt) (results []ast.Expr, ok bool) {
+	ok = true
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if len(x.Results) == 0 {
+				ok = false
+				return false
+			}
+			results = append(results, x.Results[0])
+		}
+		return ok
+	})
+	return results, ok
+}
+
+// nonNilParams returns the parameters and receiver of the function node
+// named by //nilguard:nonnil directives in its doc comment:
+//
+//	//nilguard:nonnil p, q // reason
+//	func F(p, q *T) { ... }
+//
+// The annotated pointers are trusted to be non-nil throughout the function
+// unless reassigned. Names that are not parameters or receivers of the
+// function are reported by checkAnnotations. The result is nil for function
+// literals.
+func nonNilParams(info *types.Info, node ast.Node) map[types.Object]bool {
+	fd, ok := node.(*ast.FuncDecl)
+	if !ok || fd.Doc == nil {
+		return nil
+	}
+	var names []string
+	for _, c := range fd.Doc.List {
+		if d, ok := parseDirective(c.Text); ok && d.kind == directiveNonNil {
+			names = append(names, d.pointers...)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	annotated := make(map[types.Object]bool)
+	for _, obj := range params(info, fd) {
+		if slices.Contains(names, obj.Name()) {
+			annotated[obj] = true
+		}
+	}
+	return annotated
+}
+
+// checkAnnotations reports //nilguard:nonnil directives in analyzed files
+// that have no effect: those outside the doc comment of a function
+// declaration, and names that are not a parameter or receiver of the
+// annotated function. They are reported under RuleBadAnnotation.
+func checkAnnotations(st *passState) {
+	pass := st.pass
+	for _, f := range pass.Files {
+		if f == nil {
+			continue
+		}
+		tf := pass.Fset.File(f.Pos())
+		if tf == nil || st.skipped[tf] || !st.fileIndex[tf.Name()] {
+			continue
+		}
+
+		docOf := make(map[*ast.Comment]*ast.FuncDecl)
+		for _, decl := range f.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Doc != nil {
+				for _, c := range fd.Doc.List {
+					docOf[c] = fd
+				}
+			}
+		}
+
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				d, ok := parseDirective(c.Text)
+				if !ok || d.kind != directiveNonNil {
+					continue
+				}
+				fd := docOf[c]
+				if fd == nil {
+					st.reportDirective(c, analysis.Diagnostic{
+						Pos:      c.Pos(),
+						End:      c.End(),
+						Category: RuleBadAnnotation,
+						Message:  fmt.Sprintf("annotation %s is not in the doc comment of a function declaration and has no effect", d),
+					})
+					continue
+				}
+				var names []string
+				for _, obj := range params(pass.TypesInfo, fd) {
+					names = append(names, obj.Name())
+				}
+				for _, name := range d.pointers {
+					if !slices.Contains(names, name) {
+						st.reportDirective(c, analysis.Diagnostic{
+							Pos:      c.Pos(),
+							End:      c.End(),
+							Category: RuleBadAnnotation,
+							Message:  fmt.Sprintf("annotation %s names %s, which is not a parameter or receiver of %s", d, name, fd.Name.Name),
+						})
+					}
+				}
+			}
+		}
+	}
+}
+
+// params returns the named receiver and parameters of fd.
+func params(info *types.Info, fd *ast.FuncDecl) []types.Object {
+	var objs []types.Object
+	for _, fields := range []*ast.FieldList{fd.Recv, fd.Type.Params} {
+		if fields == nil {
+			continue
+		}
+		for _, field := range fields.List {
+			for _, id := range field.Names {
+				if obj := info.Defs[id]; obj != nil {
+					objs = append(objs, obj)
+				}
+			}
+		}
+	}
+	return objs
+}
+
+// isNonNilExpr reports whether e is a pointer expression that is never nil:
+// &x, new(T), or a call to a function with the returnsNonNil fact. local
+// holds the functions of the current package known to return non-nil values.
+func isNonNilExpr(pass *analysis.Pass, local map[*types.Func]bool, e ast.Expr) bool {
+	switch x := ast.Unparen(e).(type) {
+	case *ast.UnaryExpr:
+		return x.Op == token.AND
+	case *ast.CallExpr:
+		if id, ok := ast.Unparen(x.Fun).(*ast.Ident); ok {
+			if b, ok := pass.TypesInfo.Uses[id].(*types.Builtin); ok {
+				return b.Name() == "new"
+			}
+		}
+		fn := typeutil.StaticCallee(pass.TypesInfo, x)
+		if fn == nil {
+			return false
+		}
+		if local[fn] {
+			return true
+		}
+		return pass.ImportObjectFact(fn, new(returnsNonNil))
+	}
+	return false
+}
+
+// repairs reports whether body, the then-branch of an if p == nil check,
+// assigns a non-nil value to the variable obj in one of its top-level
+// statements without assigning it again afterwards.
+func repairs(pass *analysis.Pass, body *ast.BlockStmt, obj types.Object) bool {
+	repaired := false
+	for _, stmt := range body.List {
+		as, ok := stmt.(*ast.AssignStmt)
+		if !ok {
+			if assignsTo(pass.TypesInfo, stmt, obj) {
+				repaired = false
+			}
+			continue
+		}
+		for i, lhs := range as.Lhs {
+			id, ok := ast.Unparen(lhs).(*ast.Ident)
+			if !ok || pass.TypesInfo.ObjectOf(id) != obj {
+				continue
+			}
+			repaired = len(as.Rhs) == len(as.Lhs) && as.Tok == token.ASSIGN &&
+				isNonNilExpr(pass, nil, as.Rhs[i])
+		}
+	}
+	return repaired
+}
+
+// assignsTo reports whether n contains an assignment to obj or takes its
+// address, outside nested function literals.
+func assignsTo(info *types.Info, n ast.Node, obj types.Object) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			for _, lhs := range x.Lhs {
+				if id, ok := ast.Unparen(lhs).(*ast.Ident); ok && info.ObjectOf(id) == obj {
+					found = true
+				}
+			}
+		case *ast.UnaryExpr:
+			if id, ok := ast.Unparen(x.X).(*ast.Ident); ok && x.Op == token.AND && info.ObjectOf(id) == obj {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
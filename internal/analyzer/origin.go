@@ -0,0 +1,186 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// describeOrigin returns a short description of where the pointer variable
+// obj used in fn gets its value, for diagnostics:
+//
+//	parameter, receiver, named result, captured variable,
+//	result of call to F, field load x.f, element of m,
+//	type assertion, type switch case, channel receive,
+//	range variable, variable declared without a value,
+//	local variable
+//
+// Local variables are described by their defining statement within body.
+func describeOrigin(info *types.Info, fn funcContext, body *ast.BlockStmt, obj types.Object) string {
+	if sig := funcSignature(info, fn.node); sig != nil {
+		if recv := sig.Recv(); recv != nil && recv == obj {
+			return "receiver"
+		}
+		if tupleHas(sig.Params(), obj) {
+			return "parameter"
+		}
+		if tupleHas(sig.Results(), obj) {
+			return "named result"
+		}
+	}
+
+	if obj.Pos() < fn.node.Pos() || obj.Pos() >= fn.node.End() {
+		return "captured variable"
+	}
+
+	origin := "local variable"
+	ast.Inspect(body, func(n ast.Node) bool {
+		if origin != "local variable" {
+			return false
+		}
+		switch x := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range x.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && info.Defs[id] == obj {
+					origin = valueOrigin(info, x.Rhs, len(x.Lhs), i)
+					return false
+				}
+			}
+
+		case *ast.ValueSpec:
+			for i, id := range x.Names {
+				if info.Defs[id] == obj {
+					if len(x.Values) == 0 {
+						origin = "variable declared without a value"
+					} else {
+						origin = valueOrigin(info, x.Values, len(x.Names), i)
+					}
+					return false
+				}
+			}
+
+		case *ast.CaseClause:
+			if info.Implicits[x] == obj {
+				origin = "type switch case"
+				return false
+			}
+
+		case *ast.RangeStmt:
+			for _, e := range []ast.Expr{x.Key, x.Value} {
+				if id, ok := e.(*ast.Ident); ok && info.Defs[id] == obj {
+					origin = "range variable"
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return origin
+}
+
+// valueOrigin describes the value assigned to the i-th of n variables by the
+// right-hand side rhs of an assignment or declaration.
+func valueOrigin(info *types.Info, rhs []ast.Expr, n, i int) string {
+	var e ast.Expr
+	switch {
+	case len(rhs) == n:
+		e = rhs[i]
+	case len(rhs) == 1:
+		// v, ok := m[k], x.(T), <-ch; or a, b := f()
+		e = rhs[0]
+	default:
+		return "local variable"
+	}
+	e = ast.Unparen(e)
+
+	switch x := e.(type) {
+	case *ast.CallExpr:
+		return "result of call to " + types.ExprString(x.Fun)
+	case *ast.SelectorExpr:
+		if sel := info.Selections[x]; sel != nil && sel.Kind() == types.FieldVal {
+			return "field load " + types.ExprString(x)
+		}
+	case *ast.IndexExpr:
+		return "element of " + types.ExprString(x.X)
+	case *ast.TypeAssertExpr:
+		return "type assertion"
+	case *ast.UnaryExpr:
+		if x.Op == token.ARROW {
+			return "channel receive"
+		}
+	}
+	return "local variable"
+}
+
+// funcSignature returns the signature of the function declaration or literal
+// n, or nil.
+func funcSignature(info *types.Info, n ast.Node) *types.Signature {
+	switch fn := n.(type) {
+	case *ast.FuncDecl:
+		if f, ok := info.Defs[fn.Name].(*types.Func); ok {
+			return f.Type().(*types.Signature)
+		}
+	case *ast.FuncLit:
+		if sig, ok := info.TypeOf(fn).(*types.Signature); ok {
+			return sig
+		}
+	}
+	return nil
+}
+
+// tupleHas reports whether obj is one of the variables of t.
+func tupleHas(t *types.Tuple, obj types.Object) bool {
+	for i := 0; i < t.Len(); i++ {
+		if t.At(i) == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// originSuffix returns the parenthesized context appended to diagnostics
+// about a pointer of type typ, e.g. "(parameter, type *S)".
+func originSuffix(pkg *types.Package, origin string, typ types.Type) string {
+	return fmt.Sprintf("(%s, type %s)", origin, types.TypeString(typ, types.RelativeTo(pkg)))
+}
+
+// describeUse locates the use of pointer at pos, a selector or dereference
+// whose operand is spelled pointer, and returns its kind (UseDereference,
+// UseField or UseMethod) and the type of the pointer. It returns "" and nil
+// if there is no such use.
+func describeUse(pass *analysis.Pass, pos token.Pos, pointer string) (kind string, typ types.Type) {
+	var file *ast.File
+	for _, f := range pass.Files {
+		if f.FileStart <= pos && pos < f.FileEnd {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return "", nil
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if kind != "" || n == nil || pos < n.Pos() || pos >= n.End() {
+			return false
+		}
+		switch x := n.(type) {
+		case *ast.StarExpr:
+			if x.Pos() == pos && types.ExprString(ast.Unparen(x.X)) == pointer {
+				kind, typ = UseDereference, pass.TypesInfo.TypeOf(x.X)
+			}
+		case *ast.SelectorExpr:
+			if x.Pos() == pos && types.ExprString(ast.Unparen(x.X)) == pointer {
+				kind, typ = UseField, pass.TypesInfo.TypeOf(x.X)
+				if sel := pass.TypesInfo.Selections[x]; sel != nil && sel.Kind() != types.FieldVal {
+					kind = UseMethod
+				}
+			}
+		}
+		return kind == ""
+	})
+	return kind, typ
+}
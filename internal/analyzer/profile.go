@@ -0,0 +1,73 @@
+package analyzer
+
+import "fmt"
+
+// Policy profiles, selected with Config.Profile.
+const (
+	// ProfileLenient is the v1 policy: a qualifying nil-check anywhere in a
+	// function satisfies every use of the pointer in that function.
+	ProfileLenient = "lenient"
+
+	// ProfileStandard requires each use to be dominated by a guard: it must
+	// follow an exiting or repairing if p == nil, or lie inside the branch
+	// of a p != nil condition, with no reassignment of p in between. Values
+	// from &x, new(T) and trusted constructors (functions that always return
+	// a non-nil pointer) need no check.
+	ProfileStandard = "standard"
+
+	// ProfileStrict extends ProfileStandard to pointers loaded from fields
+	// (s.conn.Close()) and elements (m[k].X), and does not trust type
+	// assertions, which may yield typed nil pointers.
+	ProfileStrict = "strict"
+)
+
+// Severities of findings, as rendered by output formats. They match SARIF's
+// result levels.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNote    = "note"
+)
+
+// profile returns the effective profile of cfg.
+func (cfg Config) profile() string {
+	if cfg.Profile == "" {
+		return ProfileLenient
+	}
+	return cfg.Profile
+}
+
+// checkProfile reports an error if cfg names an unknown profile.
+func checkProfile(cfg Config) error {
+	switch cfg.profile() {
+	case ProfileLenient, ProfileStandard, ProfileStrict:
+		return nil
+	}
+	return fmt.Errorf("invalid -profile %q (want lenient, standard or strict)", cfg.Profile)
+}
+
+// severity returns the severity of a diagnostic of the given rule under
+// profile. Stricter profiles escalate findings that teams adopting them are
+// expected to fix.
+func severity(profile, rule string) string {
+	switch rule {
+	case RuleNilAssign:
+		return SeverityError
+	case RuleNearMiss:
+		if profile == ProfileLenient {
+			return SeverityWarning
+		}
+		return SeverityError
+	case RuleUnchecked:
+		if profile == ProfileStrict {
+			return SeverityError
+		}
+		return SeverityWarning
+	case RuleUnusedSuppression, RuleSuppressionReason, RuleBadAnnotation:
+		if profile == ProfileStrict {
+			return SeverityWarning
+		}
+		return SeverityNote
+	}
+	return SeverityWarning
+}
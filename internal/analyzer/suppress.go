@@ -0,0 +1,435 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// directiveKind distinguishes the directives nilguard accepts: the
+// suppressions and the //nilguard:nonnil annotation.
+type directiveKind int
+
+const (
+	// directiveNoLint is //nolint:nilguard. It suppresses its own line, or the
+	// whole function when it appears in a function declaration's doc comment.
+	directiveNoLint directiveKind = iota
+
+	// directiveIgnore is //nilguard:ignore p q. It suppresses diagnostics
+	// about the named pointers in the innermost function containing it.
+	directiveIgnore
+
+	// directiveIgnoreFile is //nilguard:ignore-file. It suppresses every
+	// diagnostic in its file and must appear above or on the package clause.
+	directiveIgnoreFile
+
+	// directiveNonNil is //nilguard:nonnil p q. It is an annotation rather
+	// than a suppression: in a function declaration's doc comment it
+	// declares the named parameters or receiver never nil (see nonNilParams).
+	directiveNonNil
+)
+
+// directive is a parsed suppression or annotation comment.
+type directive struct {
+	kind directiveKind
+
+	// pointers lists the names given to //nilguard:ignore or
+	// //nilguard:nonnil.
+	pointers []string
+
+	// reason is the explanation following a second "//", if any.
+	reason string
+}
+
+// String returns the directive in its canonical form, without the reason.
+func (d directive) String() string {
+	switch d.kind {
+	case directiveIgnore:
+		return "//nilguard:ignore " + strings.Join(d.pointers, ", ")
+	case directiveIgnoreFile:
+		return "//nilguard:ignore-file"
+	case directiveNonNil:
+		return "//nilguard:nonnil " + strings.Join(d.pointers, ", ")
+	}
+	return "//nolint:nilguard"
+}
+
+// parseDirective parses a // comment as a nilguard directive.
+//
+// The accepted forms are
+//
+//	//nolint:nilguard
+//	//nolint:foo,nilguard,bar
+//	//nilguard:ignore p, q
+//	//nilguard:ignore-file
+//	//nilguard:nonnil p, q
+//
+// each optionally followed by "// reason". Whitespace after the leading //
+// is allowed and keywords are case-insensitive, but anything else after the
+// directive makes the comment prose rather than a directive: "// don't nolint
+// nilguard here" does not suppress anything, nor does a bare //nolint.
+func parseDirective(text string) (d directive, ok bool) {
+	body, found := strings.CutPrefix(text, "//")
+	if !found {
+		return directive{}, false
+	}
+	body, reason, _ := strings.Cut(body, "//")
+	d.reason = strings.TrimSpace(reason)
+	body = strings.TrimSpace(body)
+
+	keyword, args, _ := strings.Cut(body, " ")
+	args = strings.TrimSpace(args)
+	lower := strings.ToLower(keyword)
+
+	switch {
+	case strings.HasPrefix(lower, "nolint:"):
+		if args != "" {
+			return directive{}, false
+		}
+		for _, name := range strings.Split(lower[len("nolint:"):], ",") {
+			if name == "nilguard" {
+				d.kind = directiveNoLint
+				return d, true
+			}
+		}
+		return directive{}, false
+
+	case lower == "nilguard:ignore-file":
+		if args != "" {
+			return directive{}, false
+		}
+		d.kind = directiveIgnoreFile
+		return d, true
+
+	case lower == "nilguard:ignore":
+		for _, name := range strings.FieldsFunc(args, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			// Names are identifiers or field paths such as s.conn.
+			for _, part := range strings.Split(name, ".") {
+				if !token.IsIdentifier(part) {
+					return directive{}, false
+				}
+			}
+			d.pointers = append(d.pointers, name)
+		}
+		if len(d.pointers) == 0 {
+			return directive{}, false
+		}
+		d.kind = directiveIgnore
+		return d, true
+
+	case lower == "nilguard:nonnil":
+		for _, name := range strings.FieldsFunc(args, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			// Only parameters and receivers can be annotated.
+			if !token.IsIdentifier(name) {
+				return directive{}, false
+			}
+			d.pointers = append(d.pointers, name)
+		}
+		if len(d.pointers) == 0 {
+			return directive{}, false
+		}
+		d.kind = directiveNonNil
+		return d, true
+	}
+	return directive{}, false
+}
+
+// suppression is a directive found in the package, together with the region
+// of source it covers.
+type suppression struct {
+	directive
+	comment *ast.Comment
+
+	// pos and end delimit the suppressed region.
+	pos, end token.Pos
+
+	// used records whether the suppression has suppressed a diagnostic.
+	used bool
+
+	// trailing records whether the directive follows code on its line.
+	trailing bool
+}
+
+// covers reports whether s suppresses a diagnostic at pos about pointer.
+func (s *suppression) covers(pos token.Pos, pointer string) bool {
+	if pos < s.pos || pos >= s.end {
+		return false
+	}
+	if s.kind != directiveIgnore {
+		return true
+	}
+	for _, p := range s.pointers {
+		if p == pointer {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSuppressions collects the suppression directives of the package and
+// the regions they cover:
+//
+//   - //nolint:nilguard at the end of a line covers that line and the whole
+//     statement spanning it; on a line of its own it covers the statement
+//     starting on the next line; in the doc comment of a function declaration
+//     it covers the whole function including its function literals;
+//   - //nilguard:ignore p q covers the innermost function (or the function
+//     whose doc comment holds it) for the pointers p and q;
+//   - //nilguard:ignore-file above or on the package clause covers the file.
+//
+// An ignore-file directive elsewhere in the file is not recognized.
+func buildSuppressions(pass *analysis.Pass) []*suppression {
+	var sups []*suppression
+
+	for _, f := range pass.Files {
+		if f == nil {
+			continue
+		}
+		tf := pass.Fset.File(f.Pos())
+		if tf == nil {
+			continue
+		}
+
+		// Lazily index the code of the file for directives.
+		var lines *lineIndex
+
+		// Map the comments of function doc comments to their declarations.
+		docOf := make(map[*ast.Comment]*ast.FuncDecl)
+		for _, decl := range f.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Doc != nil {
+				for _, c := range fd.Doc.List {
+					docOf[c] = fd
+				}
+			}
+		}
+
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				d, ok := parseDirective(c.Text)
+				if !ok || d.kind == directiveNonNil {
+					continue
+				}
+				if lines == nil {
+					lines = buildLineIndex(tf, f)
+				}
+				s := &suppression{directive: d, comment: c, trailing: lines.trails(c)}
+
+				switch d.kind {
+				case directiveNoLint:
+					if fd := docOf[c]; fd != nil {
+						s.pos, s.end = fd.Pos(), fd.End()
+					} else {
+						s.pos, s.end = lines.scope(c)
+					}
+
+				case directiveIgnore:
+					fn := funcAround(f, c.Slash)
+					if fn == nil {
+						continue
+					}
+					s.pos, s.end = fn.Pos(), fn.End()
+
+				case directiveIgnoreFile:
+					if c.Slash > f.Package && tf.Line(c.Slash) != tf.Line(f.Package) {
+						continue
+					}
+					s.pos, s.end = f.FileStart, f.FileEnd
+				}
+				sups = append(sups, s)
+			}
+		}
+	}
+
+	return sups
+}
+
+// lineIndex locates code and statements by line within one file, so that
+// line-level directives can be widened to the statements they annotate, as
+// golangci-lint does for //nolint.
+type lineIndex struct {
+	tf *token.File
+
+	// first maps each line to the earliest position of code on it, taking
+	// both the start and the end of every node into account.
+	first map[int]token.Pos
+
+	// stmts lists every statement of the file other than blocks.
+	stmts []ast.Stmt
+}
+
+// buildLineIndex indexes f, whose token.File is tf.
+func buildLineIndex(tf *token.File, f *ast.File) *lineIndex {
+	idx := &lineIndex{tf: tf, first: make(map[int]token.Pos)}
+	mark := func(pos token.Pos) {
+		line := tf.Line(pos)
+		if p, ok := idx.first[line]; !ok || pos < p {
+			idx.first[line] = pos
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case nil, *ast.CommentGroup, *ast.Comment:
+			return false
+		case *ast.File:
+			return true
+		case *ast.BlockStmt:
+		case ast.Stmt:
+			idx.stmts = append(idx.stmts, n)
+		}
+		mark(n.Pos())
+		mark(n.End() - 1)
+		return true
+	})
+	return idx
+}
+
+// scope returns the region covered by the line-level directive c.
+//
+// A directive following code on its line covers that line and the innermost
+// statement spanning it, so that a directive at the end of a multi-line call
+// also covers the call's earlier lines. A directive on a line of its own
+// covers the next line and the outermost statement starting on it.
+func (idx *lineIndex) scope(c *ast.Comment) (pos, end token.Pos) {
+	line := idx.tf.Line(c.Slash)
+	if !idx.trails(c) {
+		// Standalone: annotate the following line.
+		line++
+		if line > idx.tf.LineCount() {
+			return c.Pos(), c.End()
+		}
+		pos, end = idx.lineExtent(line)
+		var outer ast.Stmt
+		for _, s := range idx.stmts {
+			if idx.tf.Line(s.Pos()) == line && (outer == nil || s.End()-s.Pos() > outer.End()-outer.Pos()) {
+				outer = s
+			}
+		}
+		if outer != nil {
+			pos, end = min(pos, outer.Pos()), max(end, outer.End())
+		}
+		return pos, end
+	}
+
+	pos, end = idx.lineExtent(line)
+	var inner ast.Stmt
+	for _, s := range idx.stmts {
+		if idx.tf.Line(s.Pos()) <= line && line <= idx.tf.Line(s.End()-1) &&
+			(inner == nil || s.End()-s.Pos() < inner.End()-inner.Pos()) {
+			inner = s
+		}
+	}
+	if inner != nil {
+		pos, end = min(pos, inner.Pos()), max(end, inner.End())
+	}
+	return pos, end
+}
+
+// trails reports whether the comment c follows code on its line.
+func (idx *lineIndex) trails(c *ast.Comment) bool {
+	p, ok := idx.first[idx.tf.Line(c.Slash)]
+	return ok && p < c.Slash
+}
+
+// lineExtent returns the positions delimiting line, including its
+// terminating newline.
+func (idx *lineIndex) lineExtent(line int) (pos, end token.Pos) {
+	pos = idx.tf.LineStart(line)
+	if line < idx.tf.LineCount() {
+		return pos, idx.tf.LineStart(line + 1)
+	}
+	return pos, token.Pos(idx.tf.Base() + idx.tf.Size() + 1)
+}
+
+// funcAround returns the innermost function declaration (including its doc
+// comment) or function literal in f that contains pos, or nil.
+func funcAround(f *ast.File, pos token.Pos) ast.Node {
+	var found ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		start := n.Pos()
+		if fd, ok := n.(*ast.FuncDecl); ok && fd.Doc != nil {
+			start = fd.Doc.Pos()
+		}
+		if pos < start || pos >= n.End() {
+			return n == f
+		}
+		switch n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			found = n
+		}
+		return true
+	})
+	return found
+}
+
+// suppressed returns the first suppression covering a diagnostic at pos
+// about pointer, or nil, marking every covering suppression as used.
+func (st *passState) suppressed(pos token.Pos, pointer string) *suppression {
+	var found *suppression
+	for _, s := range st.suppressions {
+		if s.covers(pos, pointer) {
+			s.used = true
+			if found == nil {
+				found = s
+			}
+		}
+	}
+	return found
+}
+
+// covered reports whether a suppression covers a diagnostic about pointer at
+// pos. Unlike suppressed, it does not mark the suppression used.
+func (st *passState) covered(pos token.Pos, pointer string) bool {
+	for _, s := range st.suppressions {
+		if s.covers(pos, pointer) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSuppressions reports suppressions in analyzed files that did not
+// suppress any diagnostic and, if requireReason is set, suppressions without
+// a reason.
+func checkSuppressions(st *passState, requireReason bool) {
+	for _, s := range st.suppressions {
+		tf := st.pass.Fset.File(s.comment.Slash)
+		if tf == nil || st.skipped[tf] || !st.fileIndex[tf.Name()] {
+			continue
+		}
+		if requireReason && s.reason == "" {
+			st.reportDirective(s.comment, analysis.Diagnostic{
+				Pos:      s.comment.Pos(),
+				End:      s.comment.End(),
+				Category: RuleSuppressionReason,
+				Message:  fmt.Sprintf("suppression directive %s has no reason (add \"// reason\")", s.directive),
+			})
+		}
+		if !s.used {
+			st.reportDirective(s.comment, analysis.Diagnostic{
+				Pos:            s.comment.Pos(),
+				End:            s.comment.End(),
+				Category:       RuleUnusedSuppression,
+				Message:        fmt.Sprintf("suppression directive %s does not suppress any diagnostic", s.directive),
+				SuggestedFixes: removeDirectiveFixes(st.pass, s),
+			})
+		}
+	}
+}
+
+// reportDirective emits d, a diagnostic about the directive comment c itself,
+// and records it as a Finding. It is not subject to suppression.
+func (st *passState) reportDirective(c *ast.Comment, d analysis.Diagnostic) {
+	st.pass.Report(d)
+	st.result.Findings = append(st.result.Findings, Finding{
+		Diagnostic: d,
+		Severity:   severity(st.cfg.profile(), d.Category),
+		FuncPos:    c.Pos(),
+		FuncEnd:    c.End(),
+	})
+}
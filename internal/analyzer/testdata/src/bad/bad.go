@@ -35,10 +35,11 @@ func nestedLiteralCheckOnly(p *S) {
 
 // badEqualityCheck demonstrates that `if p == nil { ... }` without an early
 // exit is not considered a qualifying check. The function continues after
-// the if, so p must still be treated as unchecked for v1.
+// the if, so p must still be treated as unchecked for v1, and the check
+// itself is reported as a near miss.
 func badEqualityCheck(p *S) {
-	if p == nil {
-		p = &S{} // no early exit; this does not count as a guard in v1
+	if p == nil { // want "nil check of \"p\" neither exits nor assigns a non-nil value"
+		println("p is nil") // no early exit and no repair
 	}
 	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
 }
@@ -76,7 +77,7 @@ func getPointer() (*S, error) { return nil, nil }
 // compoundOrNoExit demonstrates that `if p == nil || q == nil { ... }`
 // without an early exit does NOT count as a qualifying check.
 func compoundOrNoExit(p, q *S) {
-	if p == nil || q == nil {
+	if p == nil || q == nil { // want "nil check of \"p\"" "nil check of \"q\""
 		_ = "handle it" // no early exit
 	}
 	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
@@ -0,0 +1,230 @@
+// Package closures exercises closure guard inheritance (-closure-guards).
+package closures
+
+import "sort"
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// group models an errgroup.Group-style API that runs callbacks.
+type group struct{}
+
+// Go runs f. The body is irrelevant to the analyzer.
+func (g *group) Go(f func() error) {}
+
+// guardedBeforeGoroutine demonstrates that an early-exit guard in the
+// enclosing function covers a goroutine created afterward.
+func guardedBeforeGoroutine(p *S) {
+	if p == nil {
+		return
+	}
+	go func() {
+		_ = p.X
+	}()
+}
+
+// guardedBlockContainsLiteral demonstrates that a literal created inside the
+// then-branch of `if p != nil` inherits the guard.
+func guardedBlockContainsLiteral(p *S, xs []int) {
+	if p != nil {
+		sort.Slice(xs, func(i, j int) bool {
+			return xs[i]+p.X < xs[j]
+		})
+	}
+}
+
+// errgroupCallback demonstrates the errgroup pattern.
+func errgroupCallback(g *group, p *S) {
+	if g == nil || p == nil {
+		return
+	}
+	g.Go(func() error {
+		_ = p.X
+		return nil
+	})
+}
+
+// nestedLiterals demonstrates that guards flow through several levels of
+// function literals.
+func nestedLiterals(p *S) {
+	if p == nil {
+		return
+	}
+	go func() {
+		go func() {
+			_ = p.X
+		}()
+	}()
+}
+
+// loopContinue demonstrates a guard that exits the current loop iteration.
+func loopContinue(ps []*S) {
+	for _, p := range ps {
+		if p == nil {
+			continue
+		}
+		go func() {
+			_ = p.X
+		}()
+	}
+}
+
+// guardAfterLiteral demonstrates that a guard placed after the literal is
+// created does not cover it.
+func guardAfterLiteral(p *S) {
+	f := func() {
+		_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+	}
+	if p == nil {
+		return
+	}
+	f()
+}
+
+// guardInLoopLiteralAfter demonstrates that a guard inside a loop body does
+// not cover a literal created after the loop.
+func guardInLoopLiteralAfter(p *S, n int) {
+	for i := 0; i < n; i++ {
+		if p == nil {
+			break
+		}
+	}
+	go func() {
+		_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+	}()
+}
+
+// reassignedAfterGuard demonstrates that a reassignment after the guard
+// disqualifies inheritance.
+func reassignedAfterGuard(p, other *S) {
+	if p == nil {
+		return
+	}
+	go func() {
+		_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+	}()
+	p = other
+}
+
+// reassignedInOtherClosure demonstrates that a reassignment inside another
+// function literal also disqualifies inheritance.
+func reassignedInOtherClosure(p, other *S) {
+	if p == nil {
+		return
+	}
+	go func() {
+		p = other
+	}()
+	go func() {
+		_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+	}()
+}
+
+// addressTaken demonstrates that taking the address of a captured pointer
+// disqualifies inheritance, since it may be reassigned through the alias.
+func addressTaken(p *S) {
+	if p == nil {
+		return
+	}
+	reset(&p)
+	go func() {
+		_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+	}()
+}
+
+func reset(pp **S) {}
+
+// unguarded demonstrates that a captured pointer that is never checked in
+// the enclosing function is still reported inside the literal.
+func unguarded(p *S) {
+	go func() {
+		_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+	}()
+}
+
+func next() *S { return nil }
+
+// reassignedLaterInLoop demonstrates that a reassignment later in the loop
+// body disqualifies inheritance.
+func reassignedLaterInLoop(p *S) {
+	for {
+		if p == nil {
+			return
+		}
+		go func() {
+			_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+		}()
+		p = next()
+	}
+}
+
+// reassignedEarlierInLoop demonstrates that a reassignment before the guard
+// disqualifies inheritance when a loop repeats it: the goroutine of one
+// iteration races with the assignment of the next.
+func reassignedEarlierInLoop(p *S) {
+	for i := 0; i < 3; i++ {
+		p = next()
+		if p == nil {
+			return
+		}
+		go func() {
+			_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+		}()
+	}
+}
+
+// reassignedBeforeLoop demonstrates that an assignment before the loop is
+// not repeated and does not disqualify inheritance.
+func reassignedBeforeLoop(p *S) {
+	p = next()
+	for i := 0; i < 3; i++ {
+		if p == nil {
+			return
+		}
+		go func() {
+			_ = p.X
+		}()
+	}
+}
+
+// rangeReassigned demonstrates that a range statement assigning the
+// captured variable on every iteration disqualifies inheritance.
+func rangeReassigned(p *S, ps []*S) {
+	for _, p = range ps {
+		if p == nil {
+			continue
+		}
+		go func() {
+			_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+		}()
+	}
+}
+
+// reassignedInEarlierLiteral demonstrates that an assignment inside another
+// function literal disqualifies inheritance wherever the literal appears,
+// since it may run after the guard.
+func reassignedInEarlierLiteral(p *S) {
+	reset := func() { p = nil }
+	if p == nil {
+		return
+	}
+	reset()
+	func() {
+		_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+	}()
+}
+
+// reassignedInEarlierGoroutine demonstrates the same for a goroutine started
+// before the guard.
+func reassignedInEarlierGoroutine(p *S) {
+	go func() { p = nil }()
+	if p == nil {
+		return
+	}
+	func() {
+		_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+	}()
+}
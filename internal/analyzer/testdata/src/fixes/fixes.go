@@ -0,0 +1,47 @@
+// Package fixes exercises the suggested fixes attached to diagnostics.
+package fixes
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// T is a struct result type, whose zero value is T{}.
+type T struct{}
+
+func noResults(p *S) {
+	_ = p.X // want `pointer "p" is used in this function but never nil-checked`
+}
+
+func zeroValues(q *S) (T, string, bool, *S, error) {
+	if q.X > 0 { // want `pointer "q" is used in this function but never nil-checked`
+		return T{}, "", true, q, nil
+	}
+	return T{}, "", false, nil, nil
+}
+
+func namedResults(r *S) (n int, err error) {
+	n = r.X // want `pointer "r" is used in this function but never nil-checked`
+	return n, nil
+}
+
+func declaredInStatement(load func() *S) {
+	if s := load(); s.X > 0 { // want `pointer "s" is used in this function but never nil-checked`
+		println(s.X)
+	}
+}
+
+func nearMiss(m *S) int {
+	if m == nil { // want `nil check of "m" neither exits nor assigns a non-nil value`
+		println("m is nil")
+	}
+	return m.X // want `pointer "m" is used in this function but never nil-checked`
+}
+
+func unused(u *S) {
+	//nilguard:ignore u // want "suppression directive //nilguard:ignore u does not suppress any diagnostic"
+	if u != nil {
+		_ = u.X
+	}
+}
@@ -0,0 +1,14 @@
+// Package generated mixes generated and hand-written files. Only the
+// hand-written ones are analyzed by default.
+package generated
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// handwritten is reported as usual.
+func handwritten(p *S) {
+	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
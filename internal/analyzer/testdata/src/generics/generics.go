@@ -0,0 +1,91 @@
+// Package generics exercises pointer tracking through type parameters.
+package generics
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// M is a method on *S used to exercise method calls on pointer receivers.
+func (s *S) M() {}
+
+// T is a second struct used to build constraints without a core type.
+type T struct{}
+
+// M is a method on *T.
+func (t *T) M() {}
+
+// PtrTo constrains a type parameter to *E; its core type is a pointer.
+type PtrTo[E any] interface {
+	*E
+	M()
+}
+
+// ptrConstraintUnchecked demonstrates that a type parameter whose core type
+// is a pointer is tracked like a pointer.
+func ptrConstraintUnchecked[E any, P PtrTo[E]](p P) {
+	p.M() // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// ptrConstraintGuarded demonstrates that nil-checks on such type parameters
+// are recognized.
+func ptrConstraintGuarded[E any, P PtrTo[E]](p P) {
+	if p == nil {
+		return
+	}
+	p.M()
+}
+
+// inlineConstraintUnchecked demonstrates an inline constraint with a tilde
+// term.
+func inlineConstraintUnchecked[P interface{ ~*S }](p P) {
+	_ = (*p).X // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// unionConstraint demonstrates that a constraint without a core type (two
+// different pointer types) is not tracked.
+func unionConstraint[P interface {
+	*S | *T
+	M()
+}](p P) {
+	p.M()
+}
+
+// derefTypeParam demonstrates that *E values with E a type parameter are
+// tracked.
+func derefTypeParam[E any](p *E) E {
+	return *p // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// Repo is a generic repository used to exercise methods on generic types.
+type Repo[E any] struct {
+	items []*E
+}
+
+// First demonstrates an unchecked pointer receiver on a generic type.
+func (r *Repo[E]) First() *E {
+	return r.items[0] // want "pointer \"r\" is used in this function but never nil-checked"
+}
+
+// Find is a generic helper returning a pointer.
+func Find[E any](r *Repo[E]) *E {
+	if r == nil || len(r.items) == 0 {
+		return nil
+	}
+	return r.items[0]
+}
+
+// instantiated demonstrates that results of instantiated generic helpers
+// carry their concrete pointer type.
+func instantiated(r *Repo[S]) {
+	s := Find(r)
+	_ = s.X // want "pointer \"s\" is used in this function but never nil-checked"
+}
+
+// instantiatedGuarded demonstrates the guarded form.
+func instantiatedGuarded(r *Repo[S]) {
+	if s := Find(r); s != nil {
+		_ = s.X
+	}
+}
@@ -0,0 +1,141 @@
+// Package nilassign exercises the detection of pointers that are used after
+// being explicitly set to nil.
+package nilassign
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// M is a method on *S used to exercise method calls on pointer receivers.
+func (s *S) M() {}
+
+// Conn models a resource that is released by setting a field to nil.
+type Conn struct {
+	// buf is a pointer field that is cleared on close.
+	buf *S
+}
+
+// clearedAfterCheck demonstrates the "cleanup then use" bug: p has a
+// qualifying check, but is set to nil and then used again.
+func clearedAfterCheck(p *S) {
+	if p != nil {
+		p.M()
+		p = nil
+	}
+	_ = p.X // want "pointer \"p\" is used after being set to nil"
+}
+
+// clearedThenReassigned demonstrates that a reassignment between the nil
+// assignment and the use ends the nil path.
+func clearedThenReassigned(p *S) {
+	if p == nil {
+		return
+	}
+	p = nil
+	p = &S{}
+	_ = p.X
+}
+
+// clearedThenGuarded demonstrates that a nil-check after the assignment
+// guards the later use.
+func clearedThenGuarded(p *S, reset bool) {
+	if reset {
+		p = nil
+	}
+	if p != nil {
+		_ = p.X
+	}
+	if p != nil && p.X > 0 {
+		p.M()
+	}
+}
+
+// clearedOnOtherBranch demonstrates that the use must be reachable from the
+// nil assignment.
+func clearedOnOtherBranch(p *S, reset bool) {
+	if p == nil {
+		return
+	}
+	if reset {
+		p = nil
+		return
+	}
+	_ = p.X
+}
+
+// clearedInLoop demonstrates a use reached through a loop back edge.
+func clearedInLoop(p *S, n int) {
+	if p == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		p.M() // want "pointer \"p\" is used after being set to nil"
+		p = nil
+	}
+}
+
+// fieldCleared demonstrates tracking of field paths.
+func fieldCleared(c *Conn) {
+	if c == nil {
+		return
+	}
+	c.buf = nil
+	_ = c.buf.X // want "pointer \"c.buf\" is used after being set to nil"
+}
+
+// fieldClearedThenMethod demonstrates that a method call on the root may
+// reinitialize the field.
+func fieldClearedThenMethod(c *Conn) {
+	if c == nil {
+		return
+	}
+	c.buf = nil
+	c.reset()
+	_ = c.buf.X
+}
+
+func (c *Conn) reset() {
+	if c == nil {
+		return
+	}
+	c.buf = &S{}
+}
+
+// deferredCleanup demonstrates deferred literals running in reverse order:
+// the literal that clears c.buf runs before the one that reads it.
+func deferredCleanup(c *Conn) {
+	if c == nil {
+		return
+	}
+	defer func() {
+		if c == nil {
+			return
+		}
+		_ = c.buf.X // want "pointer \"c.buf\" is used after being set to nil"
+	}()
+	defer func() {
+		if c != nil {
+			c.buf = nil
+		}
+	}()
+}
+
+// deferredCleanupOrdered demonstrates that a reader deferred after the
+// cleanup runs first and is not reported.
+func deferredCleanupOrdered(c *Conn) {
+	if c == nil {
+		return
+	}
+	defer func() {
+		if c != nil {
+			c.buf = nil
+		}
+	}()
+	defer func() {
+		if c != nil && c.buf != nil {
+			_ = c.buf.X
+		}
+	}()
+}
@@ -0,0 +1,39 @@
+package nolint
+
+// annotated trusts p through its //nilguard:nonnil annotation.
+//
+//nilguard:nonnil p // validated by every caller
+func annotated(p, q *S) {
+	_ = p.X
+	_ = q.X // want "pointer \"q\" is used in this function but never nil-checked"
+}
+
+// annotatedReceiver trusts its receiver; keywords are case-insensitive.
+//
+// NILGUARD:NonNil s
+func (s *S) annotatedReceiver() int {
+	return s.X
+}
+
+// annotatedUnknown names a pointer that is not one of its parameters; p is
+// still trusted.
+//
+//nilguard:nonnil p, r // want "annotation //nilguard:nonnil p, r names r, which is not a parameter or receiver of annotatedUnknown"
+func annotatedUnknown(p *S) {
+	_ = p.X
+}
+
+// annotatedProse is not annotated: "// nilguard:nonnil is checked" is prose
+// rather than a directive.
+//
+// nilguard:nonnil is checked by the caller.
+func annotatedProse(p *S) {
+	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// annotatedInBody has no effect, since annotations apply only in the doc
+// comment of a function declaration.
+func annotatedInBody(p *S) {
+	//nilguard:nonnil p // want "annotation //nilguard:nonnil p is not in the doc comment of a function declaration and has no effect"
+	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
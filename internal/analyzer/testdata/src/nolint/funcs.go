@@ -0,0 +1,45 @@
+package nolint
+
+// funcSuppression is fully suppressed by the directive in its doc comment,
+// including its function literals.
+//
+//nolint:nilguard
+func funcSuppression(p, q *S) {
+	_ = p.X
+	func() {
+		_ = q.X
+	}()
+}
+
+// funcSuppressionDocOnly demonstrates that a function-level directive does not
+// leak into the next function.
+func funcSuppressionDocOnly(p *S) {
+	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// pointerSuppression ignores p but still reports q.
+//
+//nilguard:ignore p
+func pointerSuppression(p, q *S) {
+	_ = p.X
+	_ = q.X // want "pointer \"q\" is used in this function but never nil-checked"
+}
+
+// pointerSuppressionInBody lists several pointers inside the body, with an
+// explanation after the names.
+func pointerSuppressionInBody(p, q, r *S) {
+	// nilguard:ignore p, q // validated by the caller
+	_ = p.X
+	_ = q.X
+	_ = r.X // want "pointer \"r\" is used in this function but never nil-checked"
+}
+
+// pointerSuppressionLiteral scopes an ignore inside a function literal to that
+// literal only.
+func pointerSuppressionLiteral(p *S) {
+	func() {
+		//nilguard:ignore p
+		_ = p.X
+	}()
+	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
@@ -0,0 +1,11 @@
+// Hand-written adapter around generated code; nil handling is done by the
+// generated layer.
+
+//nilguard:ignore-file
+package nolint
+
+// ignoredFile is not reported: the whole file is suppressed.
+func ignoredFile(p *S) {
+	_ = p.X
+	p.M()
+}
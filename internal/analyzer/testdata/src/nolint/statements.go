@@ -0,0 +1,43 @@
+package nolint
+
+func consume(xs ...int) {}
+
+// precedingLine demonstrates a directive on its own line above the use.
+func precedingLine(p *S) {
+	//nolint:nilguard // p is set by the constructor
+	_ = p.X
+}
+
+// precedingLineStatement demonstrates that a directive above a multi-line
+// statement covers the whole statement.
+func precedingLineStatement(p *S) {
+	//nolint:nilguard
+	consume(
+		1,
+		p.X,
+	)
+}
+
+// endOfMultiLineCall demonstrates that a directive at the end of a multi-line
+// call covers the selector on an earlier line.
+func endOfMultiLineCall(p *S) {
+	consume(
+		p.X,
+		2,
+	) //nolint:nilguard
+}
+
+// precedingLineOnlyNext demonstrates that a directive on its own line does
+// not reach past the statement that follows it.
+func precedingLineOnlyNext(p, q *S) {
+	//nolint:nilguard
+	_ = p.X
+	_ = q.X // want "pointer \"q\" is used in this function but never nil-checked"
+}
+
+// trailingOnlyOwnStatement demonstrates that a trailing directive does not
+// cover the next statement.
+func trailingOnlyOwnStatement(p, q *S) {
+	_ = p.X //nolint:nilguard
+	_ = q.X // want "pointer \"q\" is used in this function but never nil-checked"
+}
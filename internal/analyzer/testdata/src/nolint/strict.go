@@ -0,0 +1,35 @@
+package nolint
+
+// proseMentionsNoLint demonstrates that a comment merely mentioning nolint and
+// nilguard is prose, not a directive.
+func proseMentionsNoLint(p *S) {
+	_ = p.X // don't nolint nilguard here // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// bareNoLint demonstrates that a bare //nolint does not suppress nilguard.
+func bareNoLint(p *S) {
+	_ = p.X //nolint // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// withReason demonstrates the canonical form with an explanation.
+func withReason(p *S) {
+	_ = p.X //nolint:nilguard // p is validated by every caller
+}
+
+// unusedLine demonstrates that a directive with nothing to suppress is
+// reported.
+func unusedLine(p *S) {
+	if p == nil {
+		return
+	}
+	_ = p.X //nolint:nilguard // want "suppression directive //nolint:nilguard does not suppress any diagnostic"
+}
+
+// unusedIgnore demonstrates that an ignore directive naming a pointer that is
+// never reported is itself reported.
+func unusedIgnore(p *S) {
+	//nilguard:ignore q // want "suppression directive //nilguard:ignore q does not suppress any diagnostic"
+	if p != nil {
+		_ = p.X
+	}
+}
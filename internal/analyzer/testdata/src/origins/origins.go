@@ -0,0 +1,73 @@
+// Package origins exercises the origin and type context in diagnostics.
+package origins
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+
+	// Next is a pointer field used for field loads.
+	Next *S
+}
+
+// M is a method on *S used to exercise method calls on pointer receivers.
+func (s *S) M() {}
+
+func load() *S { return nil }
+
+func parameter(p *S) {
+	_ = p.X // want `pointer "p" is used in this function but never nil-checked \(parameter, type \*S\)`
+}
+
+func (s *S) receiver() {
+	_ = s.X // want `\(receiver, type \*S\)`
+}
+
+func namedResult() (r *S) {
+	_ = r.X // want `\(named result, type \*S\)`
+	return r
+}
+
+func callResult() {
+	p := load()
+	_ = p.X // want `\(result of call to load, type \*S\)`
+}
+
+func fieldLoad(s *S) {
+	if s == nil {
+		return
+	}
+	n := s.Next
+	n.M() // want `\(field load s.Next, type \*S\)`
+}
+
+func rangeVariable(ps []*S) {
+	for _, p := range ps {
+		_ = p.X // want `\(range variable, type \*S\)`
+	}
+}
+
+func elementOf(m map[string]*S) {
+	p := m["k"]
+	_ = p.X // want `\(element of m, type \*S\)`
+}
+
+func zeroValue() {
+	var p *S
+	_ = p.X // want `\(variable declared without a value, type \*S\)`
+}
+
+func captured(p *S) func() int {
+	return func() int {
+		return p.X // want `\(captured variable, type \*S\)`
+	}
+}
+
+// nearMiss has a nil check that does not exit early; it is pointed out as
+// related information.
+func nearMiss(p *S) {
+	if p == nil { // want `nil check of "p" neither exits nor assigns a non-nil value`
+		println("nil p")
+	}
+	_ = p.X // want `\(parameter, type \*S\)`
+}
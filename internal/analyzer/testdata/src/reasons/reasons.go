@@ -0,0 +1,26 @@
+// Package reasons is analyzed with -require-reason.
+package reasons
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// explained carries a reason and is accepted.
+func explained(p *S) {
+	_ = p.X //nolint:nilguard // the caller checks p
+}
+
+// unexplained has no reason and is reported, but still suppresses.
+func unexplained(p *S) {
+	// want +1 "suppression directive //nolint:nilguard has no reason"
+	_ = p.X //nolint:nilguard
+}
+
+// unexplainedIgnore has no reason either.
+func unexplainedIgnore(p *S) {
+	// want +1 "suppression directive //nilguard:ignore p has no reason"
+	//nilguard:ignore p
+	_ = p.X
+}
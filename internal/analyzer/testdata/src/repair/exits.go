@@ -0,0 +1,64 @@
+package repair
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// The checks below end in calls that do not return, so they exit as a
+// return would and are neither near misses nor unchecked.
+
+func logFatal(p *S) {
+	if p == nil {
+		log.Fatal("p is nil")
+	}
+	_ = p.X
+}
+
+func osExit(p *S) {
+	if p == nil {
+		os.Exit(1)
+	}
+	_ = p.X
+}
+
+func goexit(p *S) {
+	if p == nil {
+		runtime.Goexit()
+	}
+	_ = p.X
+}
+
+func testFatal(t testing.TB, p *S) {
+	if p == nil {
+		t.Fatal("p is nil")
+	}
+	_ = p.X
+}
+
+// bench holds a *testing.B, whose Skip is promoted from testing.common.
+type bench struct{ b *testing.B }
+
+func (s bench) skip(p *S) {
+	if p == nil {
+		s.b.Skip("no fixture")
+	}
+	_ = p.X
+}
+
+func tbFailNow(tb testing.TB, p *S) {
+	if p == nil {
+		tb.FailNow()
+	}
+	_ = p.X
+}
+
+// testError is a near miss: t.Error lets the test continue.
+func testError(t testing.TB, p *S) {
+	if p == nil { // want `nil check of "p" neither exits nor assigns a non-nil value`
+		t.Error("p is nil")
+	}
+	_ = p.X // want `pointer "p" is used in this function but never nil-checked`
+}
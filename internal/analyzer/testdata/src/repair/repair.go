@@ -0,0 +1,85 @@
+// Package repair exercises repair guards: if p == nil checks whose body
+// assigns a non-nil value to p.
+package repair
+
+import "log"
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// NewS always returns a non-nil pointer.
+func NewS() *S { // want NewS:"returnsNonNil"
+	return &S{}
+}
+
+// newDefault returns the result of NewS, so it is non-nil too.
+func newDefault() *S { // want newDefault:"returnsNonNil"
+	return NewS()
+}
+
+// maybe may return nil.
+func maybe(ok bool) *S {
+	if ok {
+		return &S{}
+	}
+	return nil
+}
+
+func addressOf(p *S) {
+	if p == nil {
+		p = &S{}
+	}
+	_ = p.X
+}
+
+func builtinNew(p *S) {
+	if p == nil {
+		p = new(S)
+	}
+	_ = p.X
+}
+
+func nonNilCall(p *S) {
+	if p == nil {
+		log.Print("p is nil, using the default")
+		p = newDefault()
+	}
+	_ = p.X
+}
+
+// mayBeNilCall does not repair p: maybe may return nil.
+func mayBeNilCall(p *S) {
+	if p == nil { // want `nil check of "p" neither exits nor assigns a non-nil value`
+		p = maybe(false)
+	}
+	_ = p.X // want `pointer "p" is used in this function but never nil-checked`
+}
+
+// reassignedAfterRepair does not repair p: it is set again afterwards.
+func reassignedAfterRepair(p *S) {
+	if p == nil { // want `nil check of "p" neither exits nor assigns a non-nil value`
+		p = &S{}
+		p = maybe(true)
+	}
+	_ = p.X // want `pointer "p" is used in this function but never nil-checked`
+}
+
+// logOnly is the classic near miss: the nil case is noticed but not handled.
+func logOnly(p *S) {
+	if p == nil { // want `nil check of "p" neither exits nor assigns a non-nil value`
+		log.Print("p is nil")
+	}
+	_ = p.X // want `pointer "p" is used in this function but never nil-checked`
+}
+
+// logOnlyNoLaterUse is not a near miss: p is not used after the check.
+func logOnlyNoLaterUse(p *S) int {
+	x := p.X // want `pointer "p" is used in this function but never nil-checked`
+	if p == nil {
+		log.Print("p was nil")
+	}
+	return x
+}
@@ -0,0 +1,33 @@
+// Package reportall is analyzed with -report=all.
+package reportall
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// M is a method on *S used to exercise method calls on pointer receivers.
+func (s *S) M() {}
+
+// everyUse reports each use of p, not just the first.
+func everyUse(p *S) int {
+	p.M()             // want "pointer \"p\" is used in this function but never nil-checked"
+	x := p.X          // want "pointer \"p\" is used in this function but never nil-checked"
+	return x + (*p).X // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// suppressedUse demonstrates that each use is suppressed individually.
+func suppressedUse(p *S) {
+	_ = p.X //nolint:nilguard // first use is known to be safe
+	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
+
+// checked is still satisfied by a single check.
+func checked(p *S) int {
+	if p == nil {
+		return 0
+	}
+	p.M()
+	return p.X
+}
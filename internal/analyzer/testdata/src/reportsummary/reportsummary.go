@@ -0,0 +1,19 @@
+// Package reportsummary is analyzed with -report=summary.
+package reportsummary
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// M is a method on *S used to exercise method calls on pointer receivers.
+func (s *S) M() {}
+
+// summarized reports p once, listing its other two uses as related
+// information.
+func summarized(p *S) int {
+	p.M() // want "pointer \"p\" is used in this function but never nil-checked"
+	x := p.X
+	return x + (*p).X
+}
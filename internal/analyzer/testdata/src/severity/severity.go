@@ -0,0 +1,30 @@
+// Package severity has one finding of each rule whose diagnostics are the
+// same under every profile, so that their severities can be compared.
+package severity
+
+// S is a sample struct.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// unchecked uses p without any nil check.
+func unchecked(p *S) int {
+	return p.X // want `pointer "p" is used`
+}
+
+// nearMiss notices that p is nil but uses it anyway.
+func nearMiss(p *S) int {
+	n := 0
+	if p == nil { // want `nil check of "p" neither exits nor assigns a non-nil value`
+		n++
+	}
+	return n + p.X // want `pointer "p" is used`
+}
+
+// nilAssign uses p after setting it to nil. Since p is never checked, the
+// use is also unchecked.
+func nilAssign(p *S) int {
+	p = nil
+	return p.X // want `pointer "p" is used after being set to nil` `pointer "p" is used (in|where)`
+}
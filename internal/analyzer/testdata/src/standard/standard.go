@@ -0,0 +1,187 @@
+// Package standard exercises the standard profile: every use of a pointer
+// must be dominated by a guard, and assignments of &x, new(T) or the result
+// of a trusted constructor need no check.
+package standard
+
+import "log"
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+
+	// Next links S values into a list.
+	Next *S
+}
+
+// I is a sample interface for type assertions.
+type I interface{ Foo() }
+
+// Foo satisfies the I interface for *S.
+func (s *S) Foo() {}
+
+// NewS always returns a non-nil pointer.
+func NewS() *S { // want NewS:"returnsNonNil"
+	return &S{}
+}
+
+// load may return nil.
+func load() *S {
+	return nil
+}
+
+// reload may set *pp to nil.
+func reload(pp **S) {
+	if pp != nil {
+		*pp = load()
+	}
+}
+
+func useBeforeCheck(p *S) {
+	_ = p.X // want `pointer "p" is used where no nil check guards it \(parameter, type \*S\)`
+	if p != nil {
+		_ = p.X
+	}
+}
+
+func neverChecked(p *S) {
+	_ = p.X // want `pointer "p" is used in this function but never nil-checked \(parameter, type \*S\)`
+}
+
+func thenBranch(p *S) {
+	if p != nil {
+		_ = p.X
+	}
+}
+
+func elseBranch(p *S) {
+	if p == nil {
+		log.Print("no value")
+	} else {
+		_ = p.X
+	}
+}
+
+func earlyReturn(p *S) {
+	if p == nil {
+		return
+	}
+	_ = p.X
+}
+
+func repaired(p *S) {
+	if p == nil {
+		p = &S{}
+	}
+	_ = p.X
+}
+
+func shortCircuit(p *S) {
+	if p != nil && p.X > 0 {
+		return
+	}
+	if p == nil || p.X == 0 {
+		return
+	}
+	_ = p.X
+}
+
+func constructors() {
+	p := NewS()
+	_ = p.X
+	q := new(S)
+	_ = q.X
+	r := &S{}
+	_ = r.X
+	var s = &S{}
+	_ = s.X
+}
+
+func reassignedAfterCheck(p *S) {
+	if p == nil {
+		return
+	}
+	_ = p.X
+	p = load()
+	_ = p.X // want `pointer "p" is used where no nil check guards it \(parameter, type \*S\)`
+}
+
+func addressTaken(p *S) {
+	if p == nil {
+		return
+	}
+	reload(&p)
+	_ = p.X // want `pointer "p" is used where no nil check guards it \(parameter, type \*S\)`
+}
+
+func loopCondition(p *S) int {
+	sum := 0
+	for p != nil {
+		sum += p.X
+		p = p.Next
+	}
+	return sum
+}
+
+func loopReassigns(p *S) int {
+	if p == nil {
+		return 0
+	}
+	sum := 0
+	for i := 0; i < 3; i++ {
+		sum += p.X // want `pointer "p" is used where no nil check guards it \(parameter, type \*S\)`
+		p = p.Next
+	}
+	return sum
+}
+
+func switchJoin(p *S, k int) {
+	switch k {
+	case 1:
+		if p == nil {
+			return
+		}
+	default:
+		p = NewS()
+	}
+	_ = p.X
+}
+
+func switchWithoutDefault(p *S, k int) {
+	switch k {
+	case 1:
+		if p == nil {
+			return
+		}
+	}
+	_ = p.X // want `pointer "p" is used where no nil check guards it \(parameter, type \*S\)`
+}
+
+func typeAssertOk(x I) {
+	v, ok := x.(*S)
+	if ok {
+		_ = v.X
+	}
+}
+
+func typeSwitchCase(x I) {
+	switch v := x.(type) {
+	case *S:
+		_ = v.X
+	}
+}
+
+func fieldLoadNotTracked(s *S) {
+	if s == nil {
+		return
+	}
+	_ = s.Next.X
+}
+
+// fatalGuard dominates the use: log.Fatal does not return.
+func fatalGuard(p *S) {
+	if p == nil {
+		log.Fatal("p is nil")
+	}
+	_ = p.X
+}
@@ -0,0 +1,60 @@
+// Package statsstandard mirrors package stats with the diagnostics expected
+// under the standard profile.
+package statsstandard
+
+type S struct{ X int }
+
+func (s *S) M() {}
+
+func Guarded(p *S) int {
+	if p == nil {
+		return 0
+	}
+	p.M()
+	return p.X
+}
+
+func Unguarded(p, q *S) int {
+	if p == nil {
+		return 0
+	}
+	q.M() // want `pointer "q" is used in this function but never nil-checked`
+	return p.X + q.X
+}
+
+// Late is guarded under the lenient profile only: its first use precedes the
+// check.
+func Late(p *S) int {
+	x := p.X // want `pointer "p" is used where no nil check guards it`
+	if p == nil {
+		return 0
+	}
+	return x + p.X
+}
+
+func NoPointers(n int) int {
+	return n + 1
+}
+
+func Asserted(x any) int {
+	v, ok := x.(*S)
+	if !ok {
+		return 0
+	}
+	return v.X
+}
+
+// Constructed is trusted under the standard profile only.
+func Constructed() int {
+	p := &S{}
+	return p.X
+}
+
+func Suppressed(p *S) int {
+	return p.X //nolint:nilguard // checked by callers
+}
+
+//nilguard:nonnil p // validated by every caller
+func Annotated(p *S) int {
+	return p.X
+}
@@ -0,0 +1,146 @@
+// Package strict exercises the strict profile: pointers loaded from fields
+// and elements need guards too, and type assertions are not trusted since
+// they may yield typed nil pointers.
+package strict
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+
+	// Next links S values into a list.
+	Next *S
+}
+
+// I is a sample interface for type assertions.
+type I interface{ Foo() }
+
+// Foo satisfies the I interface for *S.
+func (s *S) Foo() {}
+
+// load may return nil.
+func load() *S {
+	return nil
+}
+
+func fieldLoad(s *S) {
+	if s == nil {
+		return
+	}
+	_ = s.Next.X // want `pointer "s.Next" is used where no nil check guards it \(field load s.Next, type \*S\)`
+}
+
+func fieldGuarded(s *S) {
+	if s == nil || s.Next == nil {
+		return
+	}
+	_ = s.Next.X
+	if s.Next.Next != nil {
+		_ = s.Next.Next.X
+	}
+}
+
+func fieldReassigned(s *S) {
+	if s == nil || s.Next == nil {
+		return
+	}
+	s.Next = load()
+	_ = s.Next.X // want `pointer "s.Next" is used where no nil check guards it \(field load s.Next, type \*S\)`
+}
+
+func parentReassigned(s *S) {
+	if s == nil || s.Next == nil {
+		return
+	}
+	s.Next = load()
+	_ = s.X
+}
+
+func elementLoad(m map[string]*S, k string) {
+	_ = m[k].X // want `pointer "m\[k\]" is used where no nil check guards it \(element of m, type \*S\)`
+}
+
+func elementGuarded(m map[string]*S, k string) {
+	if m[k] != nil {
+		_ = m[k].X
+	}
+}
+
+func typeAssert(x I) {
+	v, ok := x.(*S)
+	if ok {
+		_ = v.X // want `pointer "v" is used where no nil check guards it \(type assertion, type \*S\)`
+	}
+}
+
+func typeAssertChecked(x I) {
+	if v, ok := x.(*S); ok && v != nil {
+		_ = v.X
+	}
+}
+
+func typeSwitch(x I) {
+	switch v := x.(type) {
+	case *S:
+		_ = v.X // want `pointer "v" is used where no nil check guards it \(type switch case, type \*S\)`
+	}
+}
+
+// reset may set s.Next to nil.
+func (s *S) reset() {
+	if s != nil {
+		s.Next = nil
+	}
+}
+
+// drop may set s.Next to nil.
+func drop(s *S) {
+	if s != nil {
+		s.Next = nil
+	}
+}
+
+func methodCallResets(s *S) {
+	if s == nil || s.Next == nil {
+		return
+	}
+	s.reset()
+	_ = s.Next.X // want `pointer "s.Next" is used where no nil check guards it \(field load s.Next, type \*S\)`
+}
+
+func argumentResets(s *S) {
+	if s == nil || s.Next == nil {
+		return
+	}
+	drop(s)
+	_ = s.Next.X // want `pointer "s.Next" is used where no nil check guards it \(field load s.Next, type \*S\)`
+}
+
+func callInLoopResets(s *S, n int) {
+	if s == nil || s.Next == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		_ = s.Next.X // want `pointer "s.Next" is used where no nil check guards it \(field load s.Next, type \*S\)`
+		s.reset()
+	}
+}
+
+func callOnFieldKeepsGuard(s *S) {
+	if s == nil || s.Next == nil {
+		return
+	}
+	s.Next.Foo()
+	_ = s.Next.X
+}
+
+func recheckedAfterCall(s *S) {
+	if s == nil {
+		return
+	}
+	s.reset()
+	if s.Next == nil {
+		return
+	}
+	_ = s.Next.X
+}
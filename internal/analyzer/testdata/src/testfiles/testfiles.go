@@ -0,0 +1,14 @@
+// Package testfiles is analyzed with -exclude-tests, which skips functions
+// in _test.go files but still reports the rest of the package.
+package testfiles
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// use is not in a test file, so it is still reported.
+func use(p *S) int {
+	return p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
@@ -0,0 +1,12 @@
+package testfiles
+
+import "testing"
+
+// useInTest is skipped with -exclude-tests.
+func useInTest(p *S) int {
+	return p.X
+}
+
+func TestUse(t *testing.T) {
+	_ = useInTest(&S{})
+}
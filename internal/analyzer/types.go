@@ -1,6 +1,205 @@
 package analyzer
 
-import "go/token"
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Rule identifiers. Each diagnostic reported by the Analyzer carries one of
+// these as its Category.
+const (
+	// RuleUnchecked is reported for a pointer that is used in a function
+	// without any qualifying nil-check in that function (the v1 policy), or,
+	// under the standard and strict profiles, for a use not dominated by a
+	// guard.
+	RuleUnchecked = "unchecked"
+
+	// RuleNilAssign is reported for a pointer that is used on a path
+	// reachable from an explicit assignment of nil to it.
+	RuleNilAssign = "nil-assign"
+
+	// RuleNearMiss is reported for an if p == nil check whose body neither
+	// exits nor assigns a non-nil value to p, followed by a use of p.
+	RuleNearMiss = "near-miss"
+
+	// RuleUnusedSuppression is reported for a suppression directive that
+	// does not suppress any diagnostic.
+	RuleUnusedSuppression = "unused-suppression"
+
+	// RuleSuppressionReason is reported for a suppression directive without
+	// a reason when -require-reason is set.
+	RuleSuppressionReason = "suppression-reason"
+
+	// RuleBadAnnotation is reported for a //nilguard:nonnil annotation that
+	// has no effect: one outside the doc comment of a function declaration,
+	// or one naming something other than a parameter or the receiver.
+	RuleBadAnnotation = "bad-annotation"
+)
+
+// Rule describes one kind of diagnostic reported by the Analyzer.
+type Rule struct {
+	// ID is the rule identifier, used as the diagnostic Category.
+	ID string
+
+	// Summary is a one-line description of what the rule reports.
+	Summary string
+}
+
+// Rules lists every rule the Analyzer can report, in a stable order.
+var Rules = []Rule{
+	{ID: RuleUnchecked, Summary: "pointer used in a function without any nil check in that function"},
+	{ID: RuleNilAssign, Summary: "pointer used after being explicitly set to nil"},
+	{ID: RuleNearMiss, Summary: "nil check that neither exits nor repairs the pointer before it is used"},
+	{ID: RuleUnusedSuppression, Summary: "suppression directive that does not suppress any diagnostic"},
+	{ID: RuleSuppressionReason, Summary: "suppression directive without a reason"},
+	{ID: RuleBadAnnotation, Summary: "//nilguard:nonnil annotation that has no effect"},
+}
+
+// Finding is a reported diagnostic together with the context that output
+// formats need to identify it independently of its exact position.
+type Finding struct {
+	// Diagnostic is the diagnostic as reported through the analysis.Pass.
+	Diagnostic analysis.Diagnostic
+
+	// Severity is SeverityError, SeverityWarning or SeverityNote, as
+	// determined by the rule and the profile.
+	Severity string
+
+	// Func is the display name of the function containing the use, e.g.
+	// "F", "(*T).M", or "F$1" for the first function literal inside F. It is
+	// empty for diagnostics about suppression directives.
+	Func string
+
+	// FuncPos and FuncEnd delimit the enclosing function declaration or
+	// literal, or the directive for diagnostics about suppression
+	// directives.
+	FuncPos, FuncEnd token.Pos
+
+	// Pointer is the name of the pointer involved, e.g. "p" or "s.conn". It
+	// is empty for diagnostics about suppression directives.
+	Pointer string
+
+	// PointerType is the type of the pointer, e.g. "*S", qualified relative
+	// to the package. UseKind is UseDereference, UseField or UseMethod for
+	// the use the diagnostic points at (for near misses, the use following
+	// the check). Both are empty for diagnostics about suppression
+	// directives.
+	PointerType string
+	UseKind     string
+
+	// Suppression is the directive that suppressed the diagnostic, for
+	// Findings listed in Result.Suppressed, and nil otherwise.
+	Suppression *Suppression
+}
+
+// Kinds of pointer use, as recorded in Finding.UseKind.
+const (
+	// UseDereference is an explicit dereference, *p.
+	UseDereference = "dereference"
+
+	// UseField is a field selection, p.f.
+	UseField = "field"
+
+	// UseMethod is a method call or method value, p.M().
+	UseMethod = "method"
+)
+
+// Suppression describes the directive that suppressed a Finding.
+type Suppression struct {
+	// Directive is the directive in canonical form, e.g. "//nolint:nilguard".
+	Directive string
+
+	// Reason is the explanation given after the directive, if any.
+	Reason string
+}
+
+// Result is the result of the Analyzer for a single package.
+type Result struct {
+	// Findings lists the reported diagnostics in position order.
+	Findings []Finding
+
+	// Suppressed lists, in position order, the diagnostics that were not
+	// reported because a suppression directive covers them.
+	Suppressed []Finding
+
+	// Traces explains the unchecked-pointer verdicts of every analyzed
+	// function. It is only populated when Config.Trace is set.
+	Traces []FuncTrace
+
+	// Stats counts the pointer uses of every analyzed function that uses
+	// pointers, in position order.
+	Stats []FuncStats
+}
+
+// FuncStats counts the uses of the pointer variables of one function and how
+// many of them are guarded under the selected profile.
+type FuncStats struct {
+	// Func, FuncPos and FuncEnd identify the function as in Finding.
+	Func             string
+	FuncPos, FuncEnd token.Pos
+
+	// Pointers is the number of pointer variables used in the function.
+	Pointers int
+
+	// Uses is the number of their uses (dereferences, field selections and
+	// method calls), and GuardedUses the number of those that satisfy the
+	// profile's policy.
+	Uses        int
+	GuardedUses int
+
+	// SuppressedUses counts the unguarded uses covered by a suppression
+	// directive. TrustedUses counts the guarded uses that rely on trust
+	// rather than a nil check: //nilguard:nonnil annotations, comma-ok type
+	// assertions and type switch cases, and under the standard and strict
+	// profiles non-nil assignments (&x, new(T) and functions with the
+	// returnsNonNil fact).
+	SuppressedUses int
+	TrustedUses    int
+}
+
+// Kinds of TraceEvent.
+const (
+	// TraceUse records a use of a pointer (selector, method call or
+	// dereference).
+	TraceUse = "use"
+
+	// TraceGuard records a nil-check that qualifies as a guard.
+	TraceGuard = "guard"
+
+	// TraceRejected records a nil-check that does not qualify, with the
+	// reason.
+	TraceRejected = "rejected"
+
+	// TraceVerdict concludes the trace of a pointer: checked or unchecked.
+	TraceVerdict = "verdict"
+)
+
+// FuncTrace records why the pointers of one function were considered checked
+// or unchecked.
+type FuncTrace struct {
+	// Func, FuncPos and FuncEnd identify the function as in Finding.
+	Func             string
+	FuncPos, FuncEnd token.Pos
+
+	// Events lists the uses and guards in source order, followed by one
+	// verdict per used pointer.
+	Events []TraceEvent
+}
+
+// TraceEvent is one step of a FuncTrace.
+type TraceEvent struct {
+	Pos     token.Pos
+	Pointer string
+
+	// Kind is TraceUse, TraceGuard, TraceRejected or TraceVerdict.
+	Kind string
+
+	// Message describes the event, e.g. "rejected guard: if p == nil:
+	// then-branch does not exit".
+	Message string
+}
 
 // pointerUseInfo tracks how a single pointer-typed identifier is used within
 // a single function body.
@@ -15,8 +214,19 @@ type pointerUseInfo struct {
 	// we emit about this pointer.
 	firstPos token.Pos
 
+	// uses lists the positions of every recorded use, in source order.
+	uses []token.Pos
+
+	// nearMisses lists the nil-checks of this pointer that do not qualify:
+	// if p == nil statements whose body neither exits nor repairs p.
+	nearMisses []*ast.IfStmt
+
 	// hasCheck is true if we have observed at least one qualifying nil-check
 	// anywhere in the current function body for this pointer. A qualifying
 	// check is defined by the v1 policy in doc.go.
 	hasCheck bool
+
+	// trusted is true if the only qualifying checks are trusted bindings:
+	// comma-ok type assertions and type switch cases.
+	trusted bool
 }
@@ -0,0 +1,179 @@
+// Package baseline records accepted nilguard findings so that existing code
+// can be ratcheted: only findings that are not in the baseline are reported,
+// together with baseline entries that no longer match anything.
+//
+// Entries are keyed by a fingerprint of the package path, function name,
+// pointer name, rule and kind of use, which does not depend on file names or
+// line numbers and therefore survives unrelated edits.
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// DefaultFile is the conventional baseline file name, relative to the
+// directory nilguard is run from.
+const DefaultFile = ".nilguard-baseline.json"
+
+// version is the current on-disk format version.
+const version = 1
+
+// File is the on-disk baseline format.
+type File struct {
+	// Version is the format version; currently 1.
+	Version int `json:"version"`
+
+	// Entries lists the accepted findings, sorted by fingerprint inputs.
+	Entries []Entry `json:"entries"`
+}
+
+// Entry records how many findings with a given fingerprint are accepted.
+type Entry struct {
+	// Fingerprint is derived from the remaining key fields (see Key).
+	Fingerprint string `json:"fingerprint"`
+
+	Package string `json:"package"`
+	Func    string `json:"func"`
+	Pointer string `json:"pointer"`
+	Rule    string `json:"rule"`
+	UseKind string `json:"use_kind,omitempty"`
+
+	// Count is the number of findings sharing this fingerprint, e.g. several
+	// uses of the same pointer after it was set to nil.
+	Count int `json:"count"`
+}
+
+// String formats e for human-readable messages.
+func (e Entry) String() string {
+	kind := e.Rule
+	if e.UseKind != "" {
+		kind += ", " + e.UseKind
+	}
+	s := fmt.Sprintf("%s.%s: pointer %q (%s)", e.Package, e.Func, e.Pointer, kind)
+	if e.Count > 1 {
+		s += fmt.Sprintf(" x%d", e.Count)
+	}
+	return s
+}
+
+// Key returns the baseline fingerprint of f. The kind of use keeps, say, a
+// field access and a method call on the same pointer apart, so that fixing
+// one does not let a new instance of the other through.
+func Key(f driver.Finding) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{f.Package, f.Func, f.Pointer, f.Rule, f.UseKind}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// New builds a baseline accepting all of findings.
+func New(findings []driver.Finding) *File {
+	index := make(map[string]*Entry)
+	for _, f := range findings {
+		k := Key(f)
+		e, ok := index[k]
+		if !ok {
+			e = &Entry{
+				Fingerprint: k,
+				Package:     f.Package,
+				Func:        f.Func,
+				Pointer:     f.Pointer,
+				Rule:        f.Rule,
+				UseKind:     f.UseKind,
+			}
+			index[k] = e
+		}
+		e.Count++
+	}
+
+	b := &File{Version: version, Entries: make([]Entry, 0, len(index))}
+	for _, e := range index {
+		b.Entries = append(b.Entries, *e)
+	}
+	sort.Slice(b.Entries, func(i, j int) bool {
+		x, y := b.Entries[i], b.Entries[j]
+		if x.Package != y.Package {
+			return x.Package < y.Package
+		}
+		if x.Func != y.Func {
+			return x.Func < y.Func
+		}
+		if x.Pointer != y.Pointer {
+			return x.Pointer < y.Pointer
+		}
+		if x.Rule != y.Rule {
+			return x.Rule < y.Rule
+		}
+		return x.UseKind < y.UseKind
+	})
+	return b
+}
+
+// Read loads a baseline file. A missing file is reported as an error
+// satisfying errors.Is(err, fs.ErrNotExist).
+func Read(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b File
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if b.Version != version {
+		return nil, fmt.Errorf("%s: unsupported baseline version %d", path, b.Version)
+	}
+	return &b, nil
+}
+
+// Write stores b at path.
+func (b *File) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Filter removes the findings accepted by b. It returns the remaining (new)
+// findings in their original order, and the entries of b that matched fewer
+// findings than they record; for those, Count is the number of unmatched
+// findings.
+func (b *File) Filter(findings []driver.Finding) (fresh []driver.Finding, stale []Entry) {
+	fresh, _, stale = b.Match(findings)
+	return fresh, stale
+}
+
+// Match is like Filter, but also returns the findings accepted by b, in
+// their original order.
+func (b *File) Match(findings []driver.Finding) (fresh, accepted []driver.Finding, stale []Entry) {
+	remaining := make(map[string]int, len(b.Entries))
+	for _, e := range b.Entries {
+		remaining[e.Fingerprint] += e.Count
+	}
+
+	for _, f := range findings {
+		k := Key(f)
+		if remaining[k] > 0 {
+			remaining[k]--
+			accepted = append(accepted, f)
+			continue
+		}
+		fresh = append(fresh, f)
+	}
+
+	for _, e := range b.Entries {
+		if n := remaining[e.Fingerprint]; n > 0 {
+			e.Count = n
+			remaining[e.Fingerprint] = 0
+			stale = append(stale, e)
+		}
+	}
+	return fresh, accepted, stale
+}
@@ -0,0 +1,91 @@
+package baseline
+
+import (
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// finding returns a sample finding for pointer in function fn at line.
+func finding(fn, pointer string, line int) driver.Finding {
+	return driver.Finding{
+		Rule:    "unchecked",
+		Package: "example.com/pkg",
+		Func:    fn,
+		Pointer: pointer,
+		Pos:     token.Position{Filename: "/repo/pkg/a.go", Line: line},
+	}
+}
+
+// TestFilter verifies that baselined findings are suppressed regardless of
+// line shifts, and that new findings and stale entries are reported.
+func TestFilter(t *testing.T) {
+	b := New([]driver.Finding{
+		finding("F", "p", 10),
+		finding("F", "p", 12),
+		finding("G", "q", 20),
+	})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultFile)
+	if err := b.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := []driver.Finding{
+		finding("F", "p", 30), // shifted, still accepted
+		finding("F", "p", 31),
+		finding("F", "p", 32), // one more than recorded
+		finding("H", "r", 40), // new function
+	}
+	fresh, stale := b.Filter(current)
+
+	if want := []driver.Finding{current[2], current[3]}; !reflect.DeepEqual(fresh, want) {
+		t.Errorf("fresh = %+v, want %+v", fresh, want)
+	}
+	if len(stale) != 1 || stale[0].Func != "G" || stale[0].Count != 1 {
+		t.Errorf("stale = %+v, want the G entry", stale)
+	}
+}
+
+// TestMatch verifies that Match returns the accepted findings alongside the
+// fresh ones.
+func TestMatch(t *testing.T) {
+	b := New([]driver.Finding{finding("F", "p", 10)})
+	current := []driver.Finding{
+		finding("F", "p", 30),
+		finding("H", "r", 40),
+	}
+	fresh, accepted, stale := b.Match(current)
+	if !reflect.DeepEqual(fresh, current[1:]) || !reflect.DeepEqual(accepted, current[:1]) || len(stale) != 0 {
+		t.Errorf("Match = %+v, %+v, %+v; want the H finding fresh and the F finding accepted", fresh, accepted, stale)
+	}
+}
+
+// TestUseKind verifies that a field use and a method use of the same pointer
+// are separate entries, so that fixing one does not accept a new instance of
+// the other.
+func TestUseKind(t *testing.T) {
+	field := finding("F", "p", 10)
+	field.UseKind = "field"
+	method := finding("F", "p", 12)
+	method.UseKind = "method"
+
+	b := New([]driver.Finding{field, method})
+	if len(b.Entries) != 2 || b.Entries[0].UseKind != "field" || b.Entries[1].UseKind != "method" {
+		t.Fatalf("entries = %+v, want one per kind of use", b.Entries)
+	}
+
+	b = New([]driver.Finding{field})
+	fresh, stale := b.Filter([]driver.Finding{method})
+	if !reflect.DeepEqual(fresh, []driver.Finding{method}) || len(stale) != 1 {
+		t.Errorf("Filter = %+v, %+v; want the method use fresh and the field entry stale", fresh, stale)
+	}
+}
@@ -0,0 +1,255 @@
+// Package diff determines which source lines changed relative to a git
+// revision or a unified diff, so that cmd/nilguard can report only findings
+// in changed code.
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// Changes records the changed lines of each file, keyed by cleaned absolute
+// path. Line numbers refer to the new version of the file.
+type Changes map[string]*FileChanges
+
+// FileChanges records the changed lines of a single file.
+type FileChanges struct {
+	// All is true for files that are new or untracked; every line counts as
+	// changed.
+	All bool
+
+	// Lines holds the added or modified lines. A pure deletion marks the line
+	// that now follows it.
+	Lines map[int]bool
+}
+
+// Overlaps reports whether any line in [start, end] of filename changed.
+func (c Changes) Overlaps(filename string, start, end int) bool {
+	fc := c[filepath.Clean(filename)]
+	if fc == nil {
+		return false
+	}
+	if fc.All {
+		return true
+	}
+	for line := range fc.Lines {
+		if start <= line && line <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the findings whose use, or whose enclosing function,
+// overlaps a changed line.
+func (c Changes) Filter(findings []driver.Finding) []driver.Finding {
+	var out []driver.Finding
+	for _, f := range findings {
+		end := f.Pos.Line
+		if f.End.IsValid() {
+			end = f.End.Line
+		}
+		if c.Overlaps(f.Pos.Filename, f.Pos.Line, end) ||
+			f.FuncStart.IsValid() && c.Overlaps(f.FuncStart.Filename, f.FuncStart.Line, f.FuncEnd.Line) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// file returns the FileChanges for path, creating it if needed.
+func (c Changes) file(path string) *FileChanges {
+	fc := c[path]
+	if fc == nil {
+		fc = &FileChanges{Lines: make(map[int]bool)}
+		c[path] = fc
+	}
+	return fc
+}
+
+// Parse reads a unified diff (as produced by git diff or diff -u) and returns
+// the changed lines of the new files. Relative paths in the diff are resolved
+// against root; a leading "b/" prefix, as added by git, is removed, and names
+// that git quotes because of unusual characters are unquoted.
+func Parse(r io.Reader, root string) (Changes, error) {
+	changes := make(Changes)
+
+	var (
+		cur     *FileChanges // nil while outside a file or for deleted files
+		newLine int          // next line number in the new file
+		oldLeft int          // old-file lines remaining in the current hunk
+		newLeft int          // new-file lines remaining in the current hunk
+		lineNo  int
+	)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		lineNo++
+		line := sc.Text()
+
+		// Hunk bodies are consumed by count, so that content lines such as
+		// "+++ x" are never mistaken for file headers.
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				if cur != nil {
+					cur.Lines[newLine] = true
+				}
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				if cur != nil {
+					cur.Lines[newLine] = true
+				}
+				oldLeft--
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			default:
+				// Context line (possibly with its leading space stripped).
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			cur = nil
+			name := strings.TrimPrefix(line, "+++ ")
+			if i := strings.IndexByte(name, '\t'); i >= 0 {
+				name = name[:i] // diff -u appends a timestamp
+			}
+			if name == "/dev/null" {
+				continue
+			}
+			if strings.HasPrefix(name, `"`) {
+				// git's C-style quoting, with octal escapes for non-ASCII
+				// bytes, is a subset of Go's.
+				unquoted, err := strconv.Unquote(name)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: malformed file name %s", lineNo, name)
+				}
+				name = unquoted
+			}
+			name = strings.TrimPrefix(name, "b/")
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(root, filepath.FromSlash(name))
+			}
+			cur = changes.file(filepath.Clean(name))
+
+		case strings.HasPrefix(line, "@@ "):
+			var err error
+			newLine, oldLeft, newLeft, err = parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if newLeft == 0 {
+				// Pure deletion: the header names the line before the
+				// deleted block; deletions mark the line that follows it.
+				newLine++
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// parseHunkHeader parses a hunk header of the form "@@ -a,b +c,d @@" and
+// returns c together with the old and new line counts b and d (which default
+// to 1 when omitted).
+func parseHunkHeader(line string) (newStart, oldCount, newCount int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header %q", line)
+	}
+	_, oldCount, err1 := parseRange(fields[1][1:])
+	newStart, newCount, err2 := parseRange(fields[2][1:])
+	if err1 != nil || err2 != nil {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header %q", line)
+	}
+	return newStart, oldCount, newCount, nil
+}
+
+// parseRange parses "start,count" or "start" (count 1).
+func parseRange(s string) (start, count int, err error) {
+	startStr, countStr, found := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(startStr); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if found {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
+
+// FromGit returns the lines changed in the working tree of the repository
+// containing dir relative to rev, including staged, unstaged and untracked
+// (but not ignored) files. It only runs the local git binary and never
+// contacts a remote. A rev starting with "-" is rejected rather than passed
+// to git as an option.
+func FromGit(dir, rev string) (Changes, error) {
+	if strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid revision %q", rev)
+	}
+
+	// Derive the top-level directory from dir rather than using
+	// --show-toplevel, which resolves symlinks and would not match the file
+	// names reported by go/packages.
+	cdup, err := git(dir, "rev-parse", "--show-cdup")
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Join(dir, strings.TrimSpace(string(cdup)))
+
+	// Fix the prefixes, which diff.noprefix and diff.mnemonicPrefix would
+	// otherwise change, so that Parse can strip them.
+	out, err := git(root, "diff", "--no-color", "--no-ext-diff", "--no-renames", "--src-prefix=a/", "--dst-prefix=b/", "-U0", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	changes, err := Parse(bytes.NewReader(out), root)
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", rev, err)
+	}
+
+	// -z lists names verbatim rather than quoted.
+	untracked, err := git(root, "ls-files", "-z", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(string(untracked), "\x00") {
+		if name == "" {
+			continue
+		}
+		changes.file(filepath.Join(root, filepath.FromSlash(name))).All = true
+	}
+	return changes, nil
+}
+
+// git runs a git command in dir and returns its standard output.
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
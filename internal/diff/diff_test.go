@@ -0,0 +1,146 @@
+package diff
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sample = `diff --git a/pkg/a.go b/pkg/a.go
+index 1111111..2222222 100644
+--- a/pkg/a.go
++++ b/pkg/a.go
+@@ -3,4 +3,5 @@ package pkg
+ func F(p *S) {
+-	_ = p.X
++	_ = p.Y
++++	_ = p.Z
+ }
+ 
+@@ -20,2 +21,0 @@ func G() {
+-	removed()
+-	removed()
+diff --git a/pkg/gone.go b/pkg/gone.go
+deleted file mode 100644
+--- a/pkg/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package pkg
+diff --git a/pkg/new.go b/pkg/new.go
+new file mode 100644
+--- /dev/null
++++ b/pkg/new.go
+@@ -0,0 +1,2 @@
++package pkg
++func H() {}
+`
+
+// TestParse verifies changed-line extraction from a git-style diff.
+func TestParse(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	changes, err := Parse(strings.NewReader(sample), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := filepath.Join(root, "pkg", "a.go")
+	tests := []struct {
+		file       string
+		start, end int
+		want       bool
+	}{
+		{a, 3, 3, false},  // context line
+		{a, 4, 4, true},   // modified line
+		{a, 5, 5, true},   // added line that looks like a header
+		{a, 6, 20, false}, // untouched
+		{a, 22, 22, true}, // line following a pure deletion
+		{a, 1, 100, true}, // function-sized range
+		{filepath.Join(root, "pkg", "new.go"), 2, 2, true},
+		{filepath.Join(root, "pkg", "gone.go"), 1, 1, false},
+		{filepath.Join(root, "pkg", "other.go"), 1, 100, false},
+	}
+	for _, tt := range tests {
+		if got := changes.Overlaps(tt.file, tt.start, tt.end); got != tt.want {
+			t.Errorf("Overlaps(%s, %d, %d) = %v, want %v", tt.file, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+// TestParseQuoted verifies that file names quoted by git are unquoted.
+func TestParseQuoted(t *testing.T) {
+	const quoted = `diff --git "a/dir/\303\251.go" "b/dir/\303\251.go"
+--- "a/dir/\303\251.go"
++++ "b/dir/\303\251.go"
+@@ -1 +1 @@
+-package dir
++package dir // changed
+`
+	root := filepath.FromSlash("/repo")
+	changes, err := Parse(strings.NewReader(quoted), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := filepath.Join(root, "dir", "é.go"); !changes.Overlaps(name, 1, 1) {
+		t.Errorf("no change recorded for %s: %v", name, changes)
+	}
+}
+
+// TestFromGit verifies FromGit against a repository whose configuration
+// removes the diff prefixes and which contains a non-ASCII file name.
+func TestFromGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		if _, err := git(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	run("config", "user.name", "test")
+	run("config", "user.email", "test@example.com")
+	run("config", "diff.noprefix", "true")
+	write("é.go", "package m\n\nfunc F() {}\n")
+	run("add", "-A")
+	run("commit", "-q", "-m", "initial")
+
+	write("é.go", "package m\n\nfunc F() { G() }\n")
+	write("ü.go", "package m\n")
+	changes, err := FromGit(dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		line int
+		want bool
+	}{
+		{"é.go", 1, false},
+		{"é.go", 3, true},
+		{"ü.go", 1, true}, // untracked
+	}
+	for _, tt := range tests {
+		if got := changes.Overlaps(filepath.Join(dir, tt.name), tt.line, tt.line); got != tt.want {
+			t.Errorf("Overlaps(%s, %d) = %v, want %v (changes: %v)", tt.name, tt.line, got, tt.want, changes)
+		}
+	}
+
+	// A revision must not be read as an option of git diff.
+	out := filepath.Join(dir, "out.txt")
+	if _, err := FromGit(dir, "--output="+out); err == nil {
+		t.Error("FromGit accepted a revision starting with -")
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("FromGit passed the revision to git as an option")
+	}
+}
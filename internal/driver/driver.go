@@ -0,0 +1,419 @@
+// Package driver loads Go packages and runs the nilguard Analyzer over them.
+//
+// It is the go/packages-based counterpart to singlechecker used by
+// cmd/nilguard: instead of printing diagnostics directly, it returns them as
+// Findings with resolved positions and the structured context recorded in
+// the Analyzer's Result, so that callers can render them in any format.
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Finding is a single nilguard diagnostic with resolved positions.
+type Finding struct {
+	// Rule is the rule identifier (the diagnostic Category).
+	Rule string
+
+	// Message is the diagnostic message.
+	Message string
+
+	// Severity is "error", "warning" or "note" (see analyzer.SeverityError
+	// and the related constants), as determined by the rule and the profile.
+	Severity string
+
+	// Package is the import path of the package containing the finding.
+	Package string
+
+	// Func is the display name of the enclosing function, e.g. "(*T).M".
+	Func string
+
+	// FuncStart and FuncEnd delimit the enclosing function.
+	FuncStart, FuncEnd token.Position
+
+	// Pointer is the name of the pointer involved, e.g. "p" or "s.conn".
+	Pointer string
+
+	// PointerType and UseKind describe the pointer and its use; see
+	// analyzer.Finding.
+	PointerType string
+	UseKind     string
+
+	// Pos is the position of the diagnostic. End is its end position, or the
+	// zero Position if the diagnostic has no extent.
+	Pos token.Position
+	End token.Position
+
+	// Related holds secondary locations, such as the assignment that set a
+	// pointer to nil.
+	Related []Related
+
+	// Fixes lists the suggested fixes, the first of which is preferred.
+	Fixes []Fix
+
+	// Suppression records why the finding is not reported, or is nil for
+	// an active finding. Suppressed findings are only returned by Run when
+	// Config.Suppressed is set.
+	Suppression *Suppression
+}
+
+// Fix is a suggested fix for a Finding: a set of edits, described by
+// Message, to be applied together.
+type Fix struct {
+	Message string
+	Edits   []Edit
+}
+
+// Edit replaces the text between Pos and End with NewText.
+type Edit struct {
+	Pos, End token.Position
+	NewText  string
+}
+
+// Kinds of Suppression.
+const (
+	// SuppressedByDirective marks a finding covered by a //nolint:nilguard
+	// or //nilguard:ignore directive.
+	SuppressedByDirective = "directive"
+
+	// SuppressedByBaseline marks a finding accepted by the baseline file.
+	SuppressedByBaseline = "baseline"
+)
+
+// Suppression describes why a Finding is not reported.
+type Suppression struct {
+	// Kind is SuppressedByDirective or SuppressedByBaseline.
+	Kind string
+
+	// Directive is the canonical form of the directive, e.g.
+	// "//nolint:nilguard", and Reason its explanation, if any. Both are
+	// empty for baseline suppressions.
+	Directive string
+	Reason    string
+}
+
+// Related is a secondary location attached to a Finding.
+type Related struct {
+	Pos     token.Position
+	Message string
+}
+
+// Config controls how packages are loaded.
+type Config struct {
+	// Dir is the directory in which to run the build system. If empty, the
+	// current directory is used.
+	Dir string
+
+	// Tests reports whether test packages and _test.go files are loaded.
+	Tests bool
+
+	// Suppressed makes Run also return the findings suppressed by
+	// directives, with their Suppression set.
+	Suppressed bool
+}
+
+// Run loads the packages matching patterns and applies a, which must be the
+// nilguard Analyzer or one configured like it, to them. Findings are returned
+// sorted by position with duplicates (from test variants of a package)
+// removed. Errors loading or type-checking the packages are returned as an
+// error.
+func Run(a *analysis.Analyzer, patterns []string, cfg Config) ([]Finding, error) {
+	findings, _, err := RunPackages(a, patterns, cfg)
+	return findings, err
+}
+
+// RunPackages is like Run, but also returns the sorted import paths of the
+// packages analyzed, including those without findings. Test variants are
+// listed once, under the path of the package they test; the test main
+// packages synthesized by "go test" are omitted.
+func RunPackages(a *analysis.Analyzer, patterns []string, cfg Config) ([]Finding, []string, error) {
+	res, err := Collect(a, patterns, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Findings, res.Packages, nil
+}
+
+// FuncStats counts the pointer uses of one function, with resolved
+// positions. See analyzer.FuncStats.
+type FuncStats struct {
+	Package string
+	Func    string
+
+	// FuncStart and FuncEnd delimit the function.
+	FuncStart, FuncEnd token.Position
+
+	Pointers       int
+	Uses           int
+	GuardedUses    int
+	SuppressedUses int
+	TrustedUses    int
+}
+
+// Results holds everything Collect gathers from one run of the Analyzer.
+type Results struct {
+	// Findings are as returned by Run.
+	Findings []Finding
+
+	// Packages are as returned by RunPackages.
+	Packages []string
+
+	// Stats counts the pointer uses of every function that uses pointers,
+	// sorted by function position with duplicates removed.
+	Stats []FuncStats
+}
+
+// Collect is like RunPackages, but also returns the per-function statistics
+// recorded by a.
+func Collect(a *analysis.Analyzer, patterns []string, cfg Config) (*Results, error) {
+	roots, err := analyze(a, patterns, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var out Results
+	for _, act := range roots {
+		p := act.Package
+		if p.Name == "main" && strings.HasSuffix(p.PkgPath, ".test") {
+			continue
+		}
+		out.Packages = append(out.Packages, p.PkgPath)
+	}
+	slices.Sort(out.Packages)
+	out.Packages = slices.Compact(out.Packages)
+
+	type key struct {
+		pos token.Position
+		msg string
+	}
+	seen := make(map[key]bool)
+	seenFunc := make(map[token.Position]bool)
+
+	for _, act := range roots {
+		res, ok := act.Result.(*analyzer.Result)
+		if !ok {
+			continue
+		}
+		fset := act.Package.Fset
+		all := res.Findings
+		if cfg.Suppressed {
+			all = append(slices.Clip(all), res.Suppressed...)
+		}
+		for _, rf := range all {
+			f := newFinding(fset, act.Package.PkgPath, rf)
+			k := key{f.Pos, f.Message}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			out.Findings = append(out.Findings, f)
+		}
+		for _, rs := range res.Stats {
+			s := FuncStats{
+				Package:        act.Package.PkgPath,
+				Func:           rs.Func,
+				FuncStart:      fset.Position(rs.FuncPos),
+				FuncEnd:        fset.Position(rs.FuncEnd),
+				Pointers:       rs.Pointers,
+				Uses:           rs.Uses,
+				GuardedUses:    rs.GuardedUses,
+				SuppressedUses: rs.SuppressedUses,
+				TrustedUses:    rs.TrustedUses,
+			}
+			if seenFunc[s.FuncStart] {
+				continue
+			}
+			seenFunc[s.FuncStart] = true
+			out.Stats = append(out.Stats, s)
+		}
+	}
+
+	Sort(out.Findings)
+	sort.Slice(out.Stats, func(i, j int) bool {
+		return lessPosition(out.Stats[i].FuncStart, out.Stats[j].FuncStart)
+	})
+	return &out, nil
+}
+
+// Sort sorts findings by position, and by message at the same position.
+func Sort(findings []Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i].Pos, findings[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Offset != b.Offset {
+			return a.Offset < b.Offset
+		}
+		return findings[i].Message < findings[j].Message
+	})
+}
+
+// lessPosition orders positions by file name and offset.
+func lessPosition(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.Offset < b.Offset
+}
+
+// Trace explains the unchecked-pointer verdicts of one function, with
+// resolved positions. See analyzer.FuncTrace.
+type Trace struct {
+	Package string
+	Func    string
+
+	// FuncStart and FuncEnd delimit the function.
+	FuncStart, FuncEnd token.Position
+
+	Events []TraceEvent
+}
+
+// TraceEvent is one step of a Trace. See analyzer.TraceEvent.
+type TraceEvent struct {
+	Pos     token.Position
+	Pointer string
+	Kind    string
+	Message string
+}
+
+// Traces is like Run, but returns the traces recorded by a, which must have
+// tracing enabled (the -trace flag), instead of its findings. Traces are
+// sorted by function position with duplicates removed.
+func Traces(a *analysis.Analyzer, patterns []string, cfg Config) ([]Trace, error) {
+	roots, err := analyze(a, patterns, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[token.Position]bool)
+	var traces []Trace
+	for _, act := range roots {
+		res, ok := act.Result.(*analyzer.Result)
+		if !ok {
+			continue
+		}
+		fset := act.Package.Fset
+		for _, rt := range res.Traces {
+			t := Trace{
+				Package:   act.Package.PkgPath,
+				Func:      rt.Func,
+				FuncStart: fset.Position(rt.FuncPos),
+				FuncEnd:   fset.Position(rt.FuncEnd),
+			}
+			if seen[t.FuncStart] {
+				continue
+			}
+			seen[t.FuncStart] = true
+			for _, e := range rt.Events {
+				t.Events = append(t.Events, TraceEvent{
+					Pos:     fset.Position(e.Pos),
+					Pointer: e.Pointer,
+					Kind:    e.Kind,
+					Message: e.Message,
+				})
+			}
+			traces = append(traces, t)
+		}
+	}
+
+	sort.Slice(traces, func(i, j int) bool {
+		return lessPosition(traces[i].FuncStart, traces[j].FuncStart)
+	})
+	return traces, nil
+}
+
+// analyze loads the packages matching patterns and applies a to them,
+// returning the root actions. Errors loading or type-checking the packages,
+// or running a, are returned as an error.
+func analyze(a *analysis.Analyzer, patterns []string, cfg Config) ([]*checker.Action, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   cfg.Dir,
+		Tests: cfg.Tests,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages matching %v", patterns)
+	}
+
+	var errs []error
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, e := range p.Errors {
+			errs = append(errs, e)
+		}
+	})
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	graph, err := checker.Analyze([]*analysis.Analyzer{a}, pkgs, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return nil, fmt.Errorf("%s: %w", act.Package.ID, act.Err)
+		}
+	}
+	return graph.Roots, nil
+}
+
+// newFinding resolves the positions of an analyzer Finding.
+func newFinding(fset *token.FileSet, pkgPath string, rf analyzer.Finding) Finding {
+	d := rf.Diagnostic
+	f := Finding{
+		Rule:        d.Category,
+		Message:     d.Message,
+		Severity:    rf.Severity,
+		Package:     pkgPath,
+		Func:        rf.Func,
+		Pointer:     rf.Pointer,
+		PointerType: rf.PointerType,
+		UseKind:     rf.UseKind,
+		Pos:         fset.Position(d.Pos),
+
+		FuncStart: fset.Position(rf.FuncPos),
+		FuncEnd:   fset.Position(rf.FuncEnd),
+	}
+	if d.End.IsValid() {
+		f.End = fset.Position(d.End)
+	}
+	for _, r := range d.Related {
+		f.Related = append(f.Related, Related{
+			Pos:     fset.Position(r.Pos),
+			Message: r.Message,
+		})
+	}
+	for _, sf := range d.SuggestedFixes {
+		fix := Fix{Message: sf.Message}
+		for _, e := range sf.TextEdits {
+			fix.Edits = append(fix.Edits, Edit{
+				Pos:     fset.Position(e.Pos),
+				End:     fset.Position(e.End),
+				NewText: string(e.NewText),
+			})
+		}
+		f.Fixes = append(f.Fixes, fix)
+	}
+	if s := rf.Suppression; s != nil {
+		f.Suppression = &Suppression{
+			Kind:      SuppressedByDirective,
+			Directive: s.Directive,
+			Reason:    s.Reason,
+		}
+	}
+	return f
+}
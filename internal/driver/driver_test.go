@@ -0,0 +1,166 @@
+package driver
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+)
+
+// writeModule writes a throwaway module with the given files to a temporary
+// directory and returns it.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/m\n\ngo 1.21\n"
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestCollect verifies the findings, packages and statistics of a run,
+// with and without test files and suppressed findings.
+func TestCollect(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"m.go": `package m
+
+type S struct{ X int }
+
+func F(p *S) int {
+	return p.X
+}
+
+func G(p *S) int {
+	return p.X //nolint:nilguard // checked by every caller
+}
+
+func H(p *S) int {
+	if p == nil {
+		return 0
+	}
+	return p.X
+}
+`,
+		"m_test.go": `package m
+
+func helper(p *S) int {
+	return p.X
+}
+`,
+	})
+	a := analyzer.New(analyzer.Config{})
+
+	tests := []struct {
+		name       string
+		cfg        Config
+		findings   []string // Func of each finding, in order
+		suppressed int
+		stats      []string // Func of each FuncStats, in order
+	}{
+		{"default", Config{Dir: dir}, []string{"F"}, 0, []string{"F", "G", "H"}},
+		{"tests", Config{Dir: dir, Tests: true}, []string{"F", "helper"}, 0, []string{"F", "G", "H", "helper"}},
+		{"suppressed", Config{Dir: dir, Suppressed: true}, []string{"F", "G"}, 1, []string{"F", "G", "H"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Collect(a, []string{"./..."}, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Test variants are merged and the test main package omitted.
+			if want := []string{"example.com/m"}; !slices.Equal(res.Packages, want) {
+				t.Errorf("packages = %q, want %q", res.Packages, want)
+			}
+
+			var funcs []string
+			suppressed := 0
+			for _, f := range res.Findings {
+				funcs = append(funcs, f.Func)
+				if f.Rule != analyzer.RuleUnchecked || f.Package != "example.com/m" || f.Pointer != "p" || f.UseKind != analyzer.UseField {
+					t.Errorf("unexpected finding %+v", f)
+				}
+				if s := f.Suppression; s != nil {
+					suppressed++
+					if s.Kind != SuppressedByDirective || s.Directive != "//nolint:nilguard" || s.Reason != "checked by every caller" {
+						t.Errorf("suppression = %+v", s)
+					}
+				}
+			}
+			if !slices.Equal(funcs, tt.findings) || suppressed != tt.suppressed {
+				t.Errorf("findings in %q with %d suppressed, want %q with %d", funcs, suppressed, tt.findings, tt.suppressed)
+			}
+
+			funcs = nil
+			for _, s := range res.Stats {
+				funcs = append(funcs, s.Func)
+			}
+			if !slices.Equal(funcs, tt.stats) {
+				t.Errorf("stats for %q, want %q", funcs, tt.stats)
+			}
+			if s := res.Stats[2]; s.Func != "H" || s.Pointers != 1 || s.Uses != 1 || s.GuardedUses != 1 || s.FuncStart.Line != 13 {
+				t.Errorf("stats of H = %+v", s)
+			}
+			if s := res.Stats[1]; s.SuppressedUses != 1 {
+				t.Errorf("stats of G = %+v, want a suppressed use", s)
+			}
+		})
+	}
+}
+
+// TestCollectErrors verifies that packages that do not type-check are
+// reported as an error.
+func TestCollectErrors(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": "package m\n\nvar x int = \"\"\n"})
+	if _, err := Collect(analyzer.New(analyzer.Config{}), []string{"./..."}, Config{Dir: dir}); err == nil {
+		t.Error("Collect succeeded on a package with type errors")
+	}
+}
+
+// TestPreferredEdits verifies the choice of fixes to apply and their
+// application.
+func TestPreferredEdits(t *testing.T) {
+	src := []byte("a := p.X\nb := q.X\n")
+	edit := func(pos, end int, text string) Edit {
+		return Edit{
+			Pos:     token.Position{Filename: "m.go", Offset: pos},
+			End:     token.Position{Filename: "m.go", Offset: end},
+			NewText: text,
+		}
+	}
+	finding := func(edits ...Edit) Finding {
+		return Finding{Fixes: []Fix{{Message: "fix", Edits: edits}, {Message: "other"}}}
+	}
+	guardQ := edit(9, 9, "// q\n")
+	suppressed := finding(edit(0, 0, "// suppressed\n"))
+	suppressed.Suppression = &Suppression{Kind: SuppressedByBaseline}
+
+	edits, skipped := PreferredEdits([]Finding{
+		finding(guardQ),
+		finding(edit(0, 0, "// p\n")),
+		finding(guardQ),                            // the same edit again
+		finding(edit(5, 8, "Y"), edit(9, 10, "c")), // overlaps guardQ
+		suppressed,                                 // not active
+		{Fixes: nil},                               // no fix
+		finding(edit(14, 17, "q.Z")),
+	})
+	if skipped != 1 {
+		t.Errorf("skipped %d fixes, want 1", skipped)
+	}
+	got, err := Apply(src, edits["m.go"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "// p\na := p.X\n// q\nb := q.Z\n"; string(got) != want {
+		t.Errorf("Apply = %q, want %q", got, want)
+	}
+
+	if _, err := Apply(src, []Edit{edit(5, 30, "")}); err == nil {
+		t.Error("Apply accepted an edit past the end of the file")
+	}
+}
@@ -0,0 +1,73 @@
+package driver
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PreferredEdits returns, per file, the edits of the preferred (first) fix of
+// every active finding, sorted by position, as applied by the -fix flag.
+// Identical edits suggested by several findings are applied once. A fix with
+// an edit that overlaps an edit of an earlier fix is skipped as a whole, so
+// that each file is left consistent; skipped is the number of such fixes.
+func PreferredEdits(findings []Finding) (edits map[string][]Edit, skipped int) {
+	edits = make(map[string][]Edit)
+	for _, f := range findings {
+		if f.Suppression != nil || len(f.Fixes) == 0 {
+			continue
+		}
+		var add []Edit
+		conflict := false
+	fix:
+		for _, e := range f.Fixes[0].Edits {
+			for _, prev := range edits[e.Pos.Filename] {
+				switch {
+				case prev == e:
+					continue fix
+				case overlaps(prev, e):
+					conflict = true
+					break fix
+				}
+			}
+			add = append(add, e)
+		}
+		if conflict {
+			skipped++
+			continue
+		}
+		for _, e := range add {
+			edits[e.Pos.Filename] = append(edits[e.Pos.Filename], e)
+		}
+	}
+	for _, es := range edits {
+		sort.SliceStable(es, func(i, j int) bool { return es[i].Pos.Offset < es[j].Pos.Offset })
+	}
+	return edits, skipped
+}
+
+// overlaps reports whether the edits a and b of one file touch the same text.
+// Insertions at the same offset overlap, since their order would be
+// ambiguous.
+func overlaps(a, b Edit) bool {
+	if a.Pos.Offset == b.Pos.Offset {
+		return true
+	}
+	return a.Pos.Offset < b.End.Offset && b.Pos.Offset < a.End.Offset
+}
+
+// Apply returns src with edits applied. The edits must be sorted by position
+// and must not overlap, as returned by PreferredEdits.
+func Apply(src []byte, edits []Edit) ([]byte, error) {
+	var out []byte
+	last := 0
+	for _, e := range edits {
+		start, end := e.Pos.Offset, e.End.Offset
+		if start < last || end < start || end > len(src) {
+			return nil, fmt.Errorf("%s: invalid edit at offsets %d-%d", e.Pos.Filename, start, end)
+		}
+		out = append(out, src[last:start]...)
+		out = append(out, e.NewText...)
+		last = end
+	}
+	return append(out, src[last:]...), nil
+}
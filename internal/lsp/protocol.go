@@ -0,0 +1,140 @@
+package lsp
+
+import "encoding/json"
+
+// The types below are the subset of the Language Server Protocol 3.17 used
+// by the Server, with the protocol's field names.
+
+type (
+	// Position is a zero-based line and UTF-16 code unit offset.
+	Position struct {
+		Line      int `json:"line"`
+		Character int `json:"character"`
+	}
+
+	Range struct {
+		Start Position `json:"start"`
+		End   Position `json:"end"`
+	}
+
+	Location struct {
+		URI   string `json:"uri"`
+		Range Range  `json:"range"`
+	}
+
+	Diagnostic struct {
+		Range              Range                          `json:"range"`
+		Severity           int                            `json:"severity"`
+		Code               string                         `json:"code"`
+		Source             string                         `json:"source"`
+		Message            string                         `json:"message"`
+		RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+	}
+
+	DiagnosticRelatedInformation struct {
+		Location Location `json:"location"`
+		Message  string   `json:"message"`
+	}
+
+	PublishDiagnosticsParams struct {
+		URI         string       `json:"uri"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+
+	TextDocumentIdentifier struct {
+		URI string `json:"uri"`
+	}
+
+	// DocumentParams are the parameters of the didOpen, didSave and
+	// didClose notifications, of which only the document is used.
+	DocumentParams struct {
+		TextDocument TextDocumentIdentifier `json:"textDocument"`
+	}
+
+	CodeActionParams struct {
+		TextDocument TextDocumentIdentifier `json:"textDocument"`
+		Range        Range                  `json:"range"`
+	}
+
+	TextEdit struct {
+		Range   Range  `json:"range"`
+		NewText string `json:"newText"`
+	}
+
+	WorkspaceEdit struct {
+		Changes map[string][]TextEdit `json:"changes"`
+	}
+
+	CodeAction struct {
+		Title       string        `json:"title"`
+		Kind        string        `json:"kind"`
+		Diagnostics []Diagnostic  `json:"diagnostics"`
+		IsPreferred bool          `json:"isPreferred,omitempty"`
+		Edit        WorkspaceEdit `json:"edit"`
+	}
+
+	LogMessageParams struct {
+		Type    int    `json:"type"`
+		Message string `json:"message"`
+	}
+)
+
+// Diagnostic severities.
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+)
+
+// Message types of window/logMessage.
+const (
+	MessageError = 1
+	MessageInfo  = 3
+)
+
+// codeActionQuickFix is the kind of every code action offered.
+const codeActionQuickFix = "quickfix"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidParams  = -32602
+	codeMethodNotFound = -32601
+	codeInvalidRequest = -32600
+)
+
+// request is an incoming JSON-RPC request or notification; notifications
+// have no ID.
+type request struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+// response is a successful JSON-RPC response. Result is always present,
+// null for requests without a result.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+// errorResponse is a failed JSON-RPC response.
+type errorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   rpcError        `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// notification is an outgoing JSON-RPC notification.
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
@@ -0,0 +1,409 @@
+// Package lsp implements "nilguard lsp", a minimal language server that
+// speaks the Language Server Protocol over stdio.
+//
+// The server analyzes the package of a Go file whenever the file is opened
+// or saved, publishes the findings as diagnostics, and offers the analyzer's
+// suggested fixes (add a nil guard, add //nolint:nilguard with a reason,
+// annotate a parameter with //nilguard:nonnil) as quick-fix code actions.
+// Diagnostics reflect the files on disk: edits are not tracked until they
+// are saved.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/HMetcalfe/nilguard/internal/driver"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Server is a nilguard language server. It handles one client, whose
+// messages it processes in order.
+type Server struct {
+	a   *analysis.Analyzer
+	cfg driver.Config
+
+	w io.Writer
+
+	// findings holds the published findings, by file name.
+	findings map[string][]driver.Finding
+
+	// shutdown is set by the shutdown request.
+	shutdown bool
+}
+
+// NewServer returns a Server that applies a, which must be the nilguard
+// Analyzer or one configured like it, with cfg. cfg.Dir is ignored: each
+// package is loaded from its own directory.
+func NewServer(a *analysis.Analyzer, cfg driver.Config) *Server {
+	return &Server{a: a, cfg: cfg, findings: make(map[string][]driver.Finding)}
+}
+
+// errExit is returned by handle when the client sends the exit notification.
+var errExit = errors.New("exit")
+
+// Serve reads messages from r and writes responses and notifications to w
+// until the client sends the exit notification or r is closed. It returns
+// nil if the client exits after a shutdown request, and an error otherwise.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	br := bufio.NewReader(r)
+	for {
+		body, err := readMessage(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("connection closed without exit")
+			}
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.replyError(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		switch err := s.handle(&req); {
+		case errors.Is(err, errExit):
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
+
+// handle dispatches req. Errors in the request are reported to the client;
+// only errors writing to it, and errExit, are returned.
+func (s *Server) handle(req *request) error {
+	switch req.Method {
+	case "initialize":
+		return s.reply(req, map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": map[string]any{
+					"openClose": true,
+					"change":    0, // none: diagnostics follow the saved files
+					"save":      map[string]any{"includeText": false},
+				},
+				"codeActionProvider": map[string]any{
+					"codeActionKinds": []string{codeActionQuickFix},
+				},
+			},
+			"serverInfo": map[string]any{"name": s.a.Name},
+		})
+
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(req, nil)
+
+	case "exit":
+		return errExit
+
+	case "textDocument/didOpen", "textDocument/didSave":
+		var p DocumentParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return s.log(MessageError, fmt.Sprintf("%s: %v", req.Method, err))
+		}
+		return s.check(p.TextDocument.URI)
+
+	case "textDocument/didClose":
+		var p DocumentParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return s.log(MessageError, fmt.Sprintf("%s: %v", req.Method, err))
+		}
+		if filename, err := uriToPath(p.TextDocument.URI); err == nil {
+			delete(s.findings, filename)
+		}
+		return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         p.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/codeAction":
+		var p CodeActionParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return s.replyError(req, codeInvalidParams, err.Error())
+		}
+		return s.reply(req, s.codeActions(p))
+	}
+
+	if req.ID == nil {
+		return nil // other notifications, such as initialized, are ignored
+	}
+	if s.shutdown {
+		return s.replyError(req, codeInvalidRequest, "server is shut down")
+	}
+	return s.replyError(req, codeMethodNotFound, "method not supported: "+req.Method)
+}
+
+// check analyzes the package containing the file at uri and publishes the
+// diagnostics of its files. Files of the package whose findings were fixed
+// get an empty list.
+func (s *Server) check(uri string) error {
+	filename, err := uriToPath(uri)
+	if err != nil {
+		return s.log(MessageError, err.Error())
+	}
+	cfg := s.cfg
+	cfg.Dir = filepath.Dir(filename)
+	res, err := driver.Collect(s.a, []string{"file=" + filename}, cfg)
+	if err != nil {
+		return s.log(MessageError, fmt.Sprintf("analyzing %s: %v", filename, err))
+	}
+
+	byFile := map[string][]driver.Finding{filename: nil}
+	for _, f := range res.Findings {
+		byFile[f.Pos.Filename] = append(byFile[f.Pos.Filename], f)
+	}
+	for name, fs := range s.findings {
+		if _, ok := byFile[name]; !ok && len(fs) > 0 && slices.Contains(res.Packages, fs[0].Package) {
+			byFile[name] = nil
+		}
+	}
+
+	names := make([]string, 0, len(byFile))
+	for name := range byFile {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fs := byFile[name]
+		s.findings[name] = fs
+		diags := make([]Diagnostic, 0, len(fs))
+		src := newSource(name)
+		for _, f := range fs {
+			diags = append(diags, diagnostic(src, f))
+		}
+		if err := s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         pathToURI(name),
+			Diagnostics: diags,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// codeActions returns a quick fix for every suggested fix of the published
+// findings whose range intersects the requested one.
+func (s *Server) codeActions(p CodeActionParams) []CodeAction {
+	actions := []CodeAction{}
+	filename, err := uriToPath(p.TextDocument.URI)
+	if err != nil {
+		return actions
+	}
+	src := newSource(filename)
+	for _, f := range s.findings[filename] {
+		d := diagnostic(src, f)
+		if before(d.Range.End, p.Range.Start) || before(p.Range.End, d.Range.Start) {
+			continue
+		}
+		for i, fix := range f.Fixes {
+			edit := WorkspaceEdit{Changes: make(map[string][]TextEdit)}
+			for _, e := range fix.Edits {
+				esrc := src
+				if e.Pos.Filename != filename {
+					esrc = newSource(e.Pos.Filename)
+				}
+				uri := pathToURI(e.Pos.Filename)
+				edit.Changes[uri] = append(edit.Changes[uri], TextEdit{
+					Range:   Range{Start: esrc.position(e.Pos), End: esrc.position(e.End)},
+					NewText: e.NewText,
+				})
+			}
+			actions = append(actions, CodeAction{
+				Title:       fix.Message,
+				Kind:        codeActionQuickFix,
+				Diagnostics: []Diagnostic{d},
+				IsPreferred: i == 0,
+				Edit:        edit,
+			})
+		}
+	}
+	return actions
+}
+
+// diagnostic converts f, which lies in the file of src, to a Diagnostic.
+func diagnostic(src *source, f driver.Finding) Diagnostic {
+	d := Diagnostic{
+		Range:    Range{Start: src.position(f.Pos), End: src.position(f.Pos)},
+		Severity: severity(f.Severity),
+		Code:     f.Rule,
+		Source:   "nilguard",
+		Message:  f.Message,
+	}
+	if f.End.IsValid() {
+		d.Range.End = src.position(f.End)
+	}
+	for _, r := range f.Related {
+		rsrc := src
+		if r.Pos.Filename != src.filename {
+			rsrc = newSource(r.Pos.Filename)
+		}
+		pos := rsrc.position(r.Pos)
+		d.RelatedInformation = append(d.RelatedInformation, DiagnosticRelatedInformation{
+			Location: Location{URI: pathToURI(r.Pos.Filename), Range: Range{Start: pos, End: pos}},
+			Message:  r.Message,
+		})
+	}
+	return d
+}
+
+// severity maps a finding's severity onto the protocol's.
+func severity(s string) int {
+	switch s {
+	case analyzer.SeverityError:
+		return SeverityError
+	case analyzer.SeverityNote:
+		return SeverityInformation
+	}
+	return SeverityWarning
+}
+
+// before reports whether a precedes b.
+func before(a, b Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+}
+
+// source holds the lines of a file, to convert byte columns into UTF-16
+// offsets.
+type source struct {
+	filename string
+	lines    []string
+}
+
+// newSource reads filename. A file that cannot be read has no lines, and
+// its columns are taken as is.
+func newSource(filename string) *source {
+	src := &source{filename: filename}
+	if data, err := os.ReadFile(filename); err == nil {
+		src.lines = strings.Split(string(data), "\n")
+	}
+	return src
+}
+
+// position converts pos, with a one-based line and byte column, to a
+// protocol Position.
+func (src *source) position(pos token.Position) Position {
+	p := Position{Line: max(pos.Line-1, 0), Character: max(pos.Column-1, 0)}
+	if p.Line < len(src.lines) {
+		p.Character = utf16Len(src.lines[p.Line], p.Character)
+	}
+	return p
+}
+
+// utf16Len returns the number of UTF-16 code units in the first n bytes of
+// line, or in all of line if it is shorter.
+func utf16Len(line string, n int) int {
+	units := 0
+	for i, r := range line {
+		if i >= n {
+			break
+		}
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	return units
+}
+
+// uriToPath returns the file name of a file:// URI.
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI %q (want a file:// URI)", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+// pathToURI returns the file:// URI of the file name filename.
+func pathToURI(filename string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filename)}).String()
+}
+
+// reply sends the result of req, if it is a request rather than a
+// notification.
+func (s *Server) reply(req *request, result any) error {
+	if req.ID == nil {
+		return nil
+	}
+	return s.write(response{JSONRPC: "2.0", ID: *req.ID, Result: result})
+}
+
+// replyError sends an error response to req, or to an unparsable message if
+// req is nil.
+func (s *Server) replyError(req *request, code int, msg string) error {
+	id := json.RawMessage("null")
+	if req != nil {
+		if req.ID == nil {
+			return s.log(MessageError, fmt.Sprintf("%s: %s", req.Method, msg))
+		}
+		id = *req.ID
+	}
+	return s.write(errorResponse{JSONRPC: "2.0", ID: id, Error: rpcError{Code: code, Message: msg}})
+}
+
+// notify sends a notification to the client.
+func (s *Server) notify(method string, params any) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// log sends a window/logMessage notification.
+func (s *Server) log(typ int, msg string) error {
+	return s.notify("window/logMessage", LogMessageParams{Type: typ, Message: msg})
+}
+
+// write sends v as a message.
+func (s *Server) write(v any) error {
+	return writeMessage(s.w, v)
+}
+
+// readMessage reads the body of one base protocol message: headers,
+// including Content-Length, an empty line and the body.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// writeMessage writes v as the JSON body of a base protocol message.
+func writeMessage(w io.Writer, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
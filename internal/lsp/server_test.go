@@ -0,0 +1,251 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// client drives a Server over in-memory pipes, as an editor does over
+// stdio.
+type client struct {
+	t    *testing.T
+	w    io.Writer
+	r    *bufio.Reader
+	next int
+
+	done chan error
+}
+
+// startServer starts a Server for the default Analyzer and returns a client
+// connected to it.
+func startServer(t *testing.T) *client {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	c := &client{
+		t:    t,
+		w:    cw,
+		r:    bufio.NewReader(cr),
+		done: make(chan error, 1),
+	}
+	go func() {
+		err := NewServer(analyzer.New(analyzer.Config{}), driver.Config{}).Serve(sr, sw)
+		sw.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() { cw.Close() })
+	return c
+}
+
+// call sends a request and decodes its result into result, skipping the
+// notifications that precede the response.
+func (c *client) call(method string, params, result any) {
+	c.t.Helper()
+	c.next++
+	id := c.next
+	if err := writeMessage(c.w, map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.read()
+		if msg.Method != "" {
+			continue
+		}
+		if msg.ID != id {
+			c.t.Fatalf("%s: response to request %d, want %d", method, msg.ID, id)
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: error %d: %s", method, msg.Error.Code, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("%s: %v", method, err)
+			}
+		}
+		return
+	}
+}
+
+// notify sends a notification.
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	if err := writeMessage(c.w, map[string]any{"jsonrpc": "2.0", "method": method, "params": params}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// await reads messages until a notification of method arrives and decodes
+// its params into v.
+func (c *client) await(method string, v any) {
+	c.t.Helper()
+	for {
+		msg := c.read()
+		if msg.Method == "window/logMessage" {
+			c.t.Logf("server: %s", msg.Params)
+		}
+		if msg.Method != method {
+			continue
+		}
+		if err := json.Unmarshal(msg.Params, v); err != nil {
+			c.t.Fatalf("%s: %v", method, err)
+		}
+		return
+	}
+}
+
+// incoming is any message from the server.
+type incoming struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func (c *client) read() incoming {
+	c.t.Helper()
+	body, err := readMessage(c.r)
+	if err != nil {
+		c.t.Fatalf("reading from server: %v", err)
+	}
+	var msg incoming
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatalf("invalid message %s: %v", body, err)
+	}
+	return msg
+}
+
+// TestServer opens a file with an unchecked pointer, verifies the published
+// diagnostic and the offered code actions, fixes the file and verifies that
+// the diagnostic is cleared on save.
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/m\n\ngo 1.21\n")
+	write("m.go", `package m
+
+type S struct{ X int }
+
+func F(p *S) int {
+	return p.X
+}
+`)
+	uri := pathToURI(filepath.Join(dir, "m.go"))
+	doc := map[string]any{"textDocument": map[string]any{"uri": uri}}
+
+	c := startServer(t)
+	var init struct {
+		Capabilities struct {
+			CodeActionProvider struct {
+				CodeActionKinds []string
+			}
+		}
+	}
+	c.call("initialize", map[string]any{"processId": nil, "rootUri": pathToURI(dir)}, &init)
+	if kinds := init.Capabilities.CodeActionProvider.CodeActionKinds; !slices.Equal(kinds, []string{"quickfix"}) {
+		t.Errorf("code action kinds = %q, want quickfix", kinds)
+	}
+	c.notify("initialized", map[string]any{})
+
+	c.notify("textDocument/didOpen", map[string]any{"textDocument": map[string]any{
+		"uri": uri, "languageId": "go", "version": 1, "text": "",
+	}})
+	var pub PublishDiagnosticsParams
+	c.await("textDocument/publishDiagnostics", &pub)
+	if pub.URI != uri || len(pub.Diagnostics) != 1 {
+		t.Fatalf("published %+v, want one diagnostic for %s", pub, uri)
+	}
+	d := pub.Diagnostics[0]
+	if want := (Position{Line: 5, Character: 8}); d.Range.Start != want || d.Code != analyzer.RuleUnchecked ||
+		d.Severity != SeverityWarning || !strings.Contains(d.Message, `pointer "p"`) {
+		t.Errorf("diagnostic = %+v, want a warning about p at %+v", d, want)
+	}
+
+	var actions []CodeAction
+	c.call("textDocument/codeAction", map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"range":        d.Range,
+		"context":      map[string]any{"diagnostics": []Diagnostic{d}},
+	}, &actions)
+	var titles []string
+	for _, a := range actions {
+		titles = append(titles, a.Title)
+	}
+	if want := []string{"Add nil guard for p", "Suppress with //nolint:nilguard", "Annotate p as non-nil"}; !slices.Equal(titles, want) {
+		t.Fatalf("code actions = %q, want %q", titles, want)
+	}
+	edits := actions[0].Edit.Changes[uri]
+	if !actions[0].IsPreferred || len(edits) != 1 || edits[0].Range.Start != (Position{Line: 5, Character: 1}) ||
+		edits[0].NewText != "if p == nil {\n\t\treturn 0\n\t}\n\t" {
+		t.Errorf("nil guard action = %+v", actions[0])
+	}
+	if edits := actions[2].Edit.Changes[uri]; len(edits) != 1 || edits[0].Range.Start != (Position{Line: 4, Character: 0}) ||
+		!strings.HasPrefix(edits[0].NewText, "//nilguard:nonnil p // ") {
+		t.Errorf("annotation action = %+v", actions[2])
+	}
+
+	write("m.go", `package m
+
+type S struct{ X int }
+
+//nilguard:nonnil p // checked by every caller
+func F(p *S) int {
+	return p.X
+}
+`)
+	c.notify("textDocument/didSave", doc)
+	c.await("textDocument/publishDiagnostics", &pub)
+	if pub.URI != uri || len(pub.Diagnostics) != 0 {
+		t.Errorf("after fixing, published %+v, want no diagnostics", pub)
+	}
+
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
+
+// TestUnknownMethod verifies that unsupported requests get an error response
+// rather than stalling the client.
+func TestUnknownMethod(t *testing.T) {
+	c := startServer(t)
+	if err := writeMessage(c.w, map[string]any{"jsonrpc": "2.0", "id": 1, "method": "textDocument/hover", "params": map[string]any{}}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := c.read(); msg.ID != 1 || msg.Error == nil || msg.Error.Code != codeMethodNotFound {
+		t.Errorf("response = %+v, want method not found", msg)
+	}
+}
+
+// TestUTF16Len verifies the conversion of byte columns to UTF-16 offsets.
+func TestUTF16Len(t *testing.T) {
+	tests := []struct {
+		line string
+		n    int
+		want int
+	}{
+		{"\treturn p.X", 8, 8},
+		{"s := \"é\"; p.X", 12, 11}, // é is 2 bytes, 1 unit
+		{"s := \"😀\"; p.X", 13, 11}, // 😀 is 4 bytes, 2 units
+		{"short", 10, 5},
+	}
+	for _, tt := range tests {
+		if got := utf16Len(tt.line, tt.n); got != tt.want {
+			t.Errorf("utf16Len(%q, %d) = %d, want %d", tt.line, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// WriteGitHub writes findings as GitHub Actions workflow commands, one per
+// line, so that they are shown as annotations on the changed files:
+//
+//	::warning file=pkg/a.go,line=10,col=2,title=nilguard (unchecked)::pointer "p" is used ...
+//
+// The command is ::error, ::warning or ::notice according to the finding's
+// severity. File names are relative to root, which should be the repository
+// root (normally $GITHUB_WORKSPACE).
+func WriteGitHub(w io.Writer, findings []driver.Finding, root string) error {
+	for _, f := range findings {
+		props := []string{
+			"file=" + escapeProperty(relPath(root, f.Pos.Filename)),
+			"line=" + strconv.Itoa(f.Pos.Line),
+			"col=" + strconv.Itoa(f.Pos.Column),
+		}
+		if f.End.IsValid() && f.End.Filename == f.Pos.Filename {
+			props = append(props,
+				"endLine="+strconv.Itoa(f.End.Line),
+				"endColumn="+strconv.Itoa(f.End.Column))
+		}
+		props = append(props, "title="+escapeProperty("nilguard ("+f.Rule+")"))
+
+		msg := f.Message
+		for _, r := range f.Related {
+			msg += fmt.Sprintf("\n%s:%d:%d: %s", relPath(root, r.Pos.Filename), r.Pos.Line, r.Pos.Column, r.Message)
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", githubCommand(f.Severity), strings.Join(props, ","), escapeData(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// githubCommand returns the workflow command for severity.
+func githubCommand(severity string) string {
+	switch severity {
+	case analyzer.SeverityError:
+		return "error"
+	case analyzer.SeverityNote:
+		return "notice"
+	}
+	return "warning"
+}
+
+// escapeData escapes s for the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes s for a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// gitlabIssue is a GitLab Code Quality issue, the subset of the Code Climate
+// issue format that GitLab reads.
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end,omitempty"`
+}
+
+// WriteGitLab writes findings as a GitLab Code Quality report, a JSON array
+// of issues to be published with artifacts:reports:codequality so that they
+// are shown on merge requests. Paths are relative to root, which should be
+// the repository root (normally $CI_PROJECT_DIR). Issues are identified by
+// the findings' Fingerprints, which survive line shifts and are unique, since
+// GitLab drops issues whose fingerprint repeats.
+func WriteGitLab(w io.Writer, findings []driver.Finding, root string) error {
+	issues := make([]gitlabIssue, 0, len(findings))
+	fps := Fingerprints(findings, root)
+	for i, f := range findings {
+		issue := gitlabIssue{
+			Description: f.Message,
+			CheckName:   "nilguard/" + f.Rule,
+			Fingerprint: fps[i],
+			Severity:    gitlabSeverity(f.Severity),
+			Location: gitlabLocation{
+				Path:  relPath(root, f.Pos.Filename),
+				Lines: gitlabLines{Begin: f.Pos.Line},
+			},
+		}
+		if f.End.IsValid() && f.End.Line > f.Pos.Line {
+			issue.Location.Lines.End = f.End.Line
+		}
+		issues = append(issues, issue)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
+
+// gitlabSeverity maps a finding's severity onto the Code Quality scale
+// (info, minor, major, critical, blocker).
+func gitlabSeverity(severity string) string {
+	switch severity {
+	case analyzer.SeverityError:
+		return "major"
+	case analyzer.SeverityNote:
+		return "info"
+	}
+	return "minor"
+}
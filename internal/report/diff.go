@@ -0,0 +1,153 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// diffContext is the number of unchanged lines shown around each change by
+// WriteDiff, as in diff -u.
+const diffContext = 3
+
+// diffRegion is a run of whole lines [start, end) of the old file that the
+// edits replace with the lines of text.
+type diffRegion struct {
+	start, end int
+	text       string
+}
+
+// WriteDiff writes the changes that edits make to src as a unified diff of
+// the file name, with the "(old)" and "(new)" headers printed by -fix -diff
+// in singlechecker. The edits must be sorted by position and must not
+// overlap, as returned by driver.PreferredEdits.
+func WriteDiff(w io.Writer, name string, src []byte, edits []driver.Edit) error {
+	text := string(src)
+	lines := splitLines(text)
+	starts := make([]int, len(lines)+1)
+	for i, l := range lines {
+		starts[i+1] = starts[i] + len(l)
+	}
+	// lineOf returns the index of the line containing offset; the offset
+	// just past the last newline belongs to an empty line after the last.
+	lineOf := func(offset int) int {
+		i := 0
+		for i < len(lines) && starts[i+1] <= offset {
+			i++
+		}
+		return i
+	}
+
+	// Expand every edit to the whole lines it touches, merging edits that
+	// share a line.
+	type group struct {
+		start, end int
+		edits      []driver.Edit
+	}
+	var groups []group
+	for _, e := range edits {
+		pos, end := e.Pos.Offset, e.End.Offset
+		if pos < 0 || end < pos || end > len(text) {
+			return fmt.Errorf("%s: invalid edit at offsets %d-%d", name, pos, end)
+		}
+		start, last := lineOf(pos), lineOf(pos)
+		if end > pos {
+			last = lineOf(end - 1)
+		}
+		g := group{start: start, end: min(last+1, len(lines)), edits: []driver.Edit{e}}
+		if n := len(groups); n > 0 && groups[n-1].end > g.start {
+			groups[n-1].end = max(groups[n-1].end, g.end)
+			groups[n-1].edits = append(groups[n-1].edits, e)
+			continue
+		}
+		groups = append(groups, g)
+	}
+	regions := make([]diffRegion, len(groups))
+	for i, g := range groups {
+		var b strings.Builder
+		at := starts[g.start]
+		for _, e := range g.edits {
+			b.WriteString(text[at:e.Pos.Offset])
+			b.WriteString(e.NewText)
+			at = e.End.Offset
+		}
+		b.WriteString(text[at:starts[g.end]])
+		regions[i] = diffRegion{start: g.start, end: g.end, text: b.String()}
+	}
+	if len(regions) == 0 {
+		return nil
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s (old)\n+++ %s (new)\n", name, name)
+	delta := 0 // lines added minus lines removed by earlier hunks
+	for i := 0; i < len(regions); {
+		// Gather the regions whose context overlaps into one hunk.
+		j := i + 1
+		for j < len(regions) && regions[j].start-regions[j-1].end <= 2*diffContext {
+			j++
+		}
+		from := max(0, regions[i].start-diffContext)
+		to := min(len(lines), regions[j-1].end+diffContext)
+
+		var body []string
+		oldCount, newCount := 0, 0
+		context := func(a, b int) {
+			for _, l := range lines[a:b] {
+				body = append(body, " "+l)
+			}
+			oldCount += b - a
+			newCount += b - a
+		}
+		at := from
+		for _, r := range regions[i:j] {
+			context(at, r.start)
+			for _, l := range lines[r.start:r.end] {
+				body = append(body, "-"+l)
+			}
+			added := splitLines(r.text)
+			for _, l := range added {
+				body = append(body, "+"+l)
+			}
+			oldCount += r.end - r.start
+			newCount += len(added)
+			at = r.end
+		}
+		context(at, to)
+
+		fmt.Fprintf(bw, "@@ -%s +%s @@\n", hunkRange(from+1, oldCount), hunkRange(from+1+delta, newCount))
+		for _, l := range body {
+			bw.WriteString(l)
+			if !strings.HasSuffix(l, "\n") {
+				bw.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		delta += newCount - oldCount
+		i = j
+	}
+	return bw.Flush()
+}
+
+// hunkRange formats the range of a hunk header. An empty range is given by
+// the line before it, as diff -u does.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits s after each newline. Only the last line may lack one.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
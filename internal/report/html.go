@@ -0,0 +1,283 @@
+package report
+
+import (
+	"fmt"
+	"go/token"
+	"html/template"
+	"io"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// excerptContext is the number of source lines shown before and after the
+// line of a finding in the HTML report.
+const excerptContext = 2
+
+// htmlCounts are the totals shown for the whole report, a package or a
+// function.
+type htmlCounts struct {
+	Functions   int
+	Pointers    int
+	Uses        int
+	GuardedUses int
+	Findings    int
+	Suppressed  int
+}
+
+// Coverage returns the share of guarded uses as a percentage, or "-" if
+// there are no uses.
+func (c htmlCounts) Coverage() string {
+	if c.Uses == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(c.GuardedUses)/float64(c.Uses))
+}
+
+// add adds the counts of o to c.
+func (c *htmlCounts) add(o htmlCounts) {
+	c.Functions += o.Functions
+	c.Pointers += o.Pointers
+	c.Uses += o.Uses
+	c.GuardedUses += o.GuardedUses
+	c.Findings += o.Findings
+	c.Suppressed += o.Suppressed
+}
+
+type (
+	htmlReport struct {
+		Total      htmlCounts
+		Packages   []*htmlPackage
+		Findings   []htmlFinding
+		Suppressed []htmlFinding
+	}
+
+	htmlPackage struct {
+		Path string
+		htmlCounts
+		Funcs []*htmlFunc
+	}
+
+	htmlFunc struct {
+		Name     string
+		Location string
+		htmlCounts
+	}
+
+	htmlFinding struct {
+		Severity string
+		Rule     string
+		Message  string
+		Location string
+		Package  string
+		Func     string
+		Related  []htmlRelated
+		Excerpt  []htmlLine
+
+		// Kind, Directive and Reason describe the suppression of a
+		// suppressed finding.
+		Kind      string
+		Directive string
+		Reason    string
+	}
+
+	htmlRelated struct {
+		Location string
+		Message  string
+	}
+
+	htmlLine struct {
+		Number  int
+		Text    string
+		Current bool
+	}
+)
+
+// WriteHTML writes a self-contained HTML report, with no external resources,
+// for sharing with people who do not run nilguard: totals per package and
+// per function, including pointer uses and guarded uses from stats, the
+// active findings with excerpts of their source, and the suppressed findings
+// with their reasons. Findings with a Suppression are listed as suppressed.
+//
+// Every package of pkgs is listed, with or without findings. Source excerpts
+// are read from the files named by the findings, and omitted for files that
+// cannot be read. File names are relative to root.
+func WriteHTML(w io.Writer, findings []driver.Finding, stats []driver.FuncStats, pkgs []string, root string) error {
+	var r htmlReport
+	byPath := make(map[string]*htmlPackage)
+	pkg := func(path string) *htmlPackage {
+		p, ok := byPath[path]
+		if !ok {
+			p = &htmlPackage{Path: path}
+			byPath[path] = p
+			r.Packages = append(r.Packages, p)
+		}
+		return p
+	}
+	for _, path := range pkgs {
+		pkg(path)
+	}
+
+	type funcKey struct {
+		pkg   string
+		start token.Position
+	}
+	funcs := make(map[funcKey]*htmlFunc)
+	for _, s := range stats {
+		fn := &htmlFunc{
+			Name:     s.Func,
+			Location: fmt.Sprintf("%s:%d", relPath(root, s.FuncStart.Filename), s.FuncStart.Line),
+			htmlCounts: htmlCounts{
+				Functions:   1,
+				Pointers:    s.Pointers,
+				Uses:        s.Uses,
+				GuardedUses: s.GuardedUses,
+			},
+		}
+		funcs[funcKey{s.Package, s.FuncStart}] = fn
+		p := pkg(s.Package)
+		p.Funcs = append(p.Funcs, fn)
+	}
+
+	sources := make(map[string][]string)
+	for _, f := range findings {
+		hf := htmlFinding{
+			Severity: f.Severity,
+			Rule:     f.Rule,
+			Message:  f.Message,
+			Location: fmt.Sprintf("%s:%d:%d", relPath(root, f.Pos.Filename), f.Pos.Line, f.Pos.Column),
+			Package:  f.Package,
+			Func:     f.Func,
+			Excerpt:  excerpt(sources, f.Pos),
+		}
+		for _, rel := range f.Related {
+			hf.Related = append(hf.Related, htmlRelated{
+				Location: fmt.Sprintf("%s:%d:%d", relPath(root, rel.Pos.Filename), rel.Pos.Line, rel.Pos.Column),
+				Message:  rel.Message,
+			})
+		}
+
+		// Count the finding for its function, if it has one, and otherwise
+		// for its package only.
+		counts := &pkg(f.Package).htmlCounts
+		if f.Func != "" {
+			k := funcKey{f.Package, f.FuncStart}
+			fn, ok := funcs[k]
+			if !ok {
+				fn = &htmlFunc{
+					Name:       f.Func,
+					Location:   fmt.Sprintf("%s:%d", relPath(root, f.FuncStart.Filename), f.FuncStart.Line),
+					htmlCounts: htmlCounts{Functions: 1},
+				}
+				funcs[k] = fn
+				p := pkg(f.Package)
+				p.Funcs = append(p.Funcs, fn)
+			}
+			counts = &fn.htmlCounts
+		}
+
+		if s := f.Suppression; s != nil {
+			hf.Kind, hf.Directive, hf.Reason = s.Kind, s.Directive, s.Reason
+			counts.Suppressed++
+			r.Suppressed = append(r.Suppressed, hf)
+		} else {
+			counts.Findings++
+			r.Findings = append(r.Findings, hf)
+		}
+	}
+
+	for _, p := range r.Packages {
+		for _, fn := range p.Funcs {
+			p.add(fn.htmlCounts)
+		}
+		r.Total.add(p.htmlCounts)
+	}
+	return htmlTemplate.Execute(w, r)
+}
+
+// excerpt returns the lines of source around pos, reading and caching the
+// file in sources, or nil if the file cannot be read.
+func excerpt(sources map[string][]string, pos token.Position) []htmlLine {
+	lines := sourceLines(sources, pos.Filename)
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return nil
+	}
+	var out []htmlLine
+	for n := max(1, pos.Line-excerptContext); n <= min(len(lines), pos.Line+excerptContext); n++ {
+		out = append(out, htmlLine{Number: n, Text: lines[n-1], Current: n == pos.Line})
+	}
+	return out
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>nilguard report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #f2f2f2; }
+tr.func td:first-child { padding-left: 2em; }
+.error { color: #b00020; }
+.warning { color: #a15c00; }
+.note { color: #3060a0; }
+.finding { border: 1px solid #ddd; border-radius: 4px; padding: 0.6em 1em; margin-bottom: 1em; }
+.finding p { margin: 0.3em 0; }
+pre { background: #f7f7f7; padding: 0.5em; overflow-x: auto; tab-size: 4; }
+pre .current { background: #ffe9a8; display: inline-block; width: 100%; }
+.loc, pre { font-family: ui-monospace, monospace; }
+</style>
+</head>
+<body>
+<h1>nilguard report</h1>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Packages</th><th>Functions</th><th>Pointers</th><th>Uses</th><th>Guarded uses</th><th>Coverage</th><th>Findings</th><th>Suppressed</th></tr>
+<tr><td>{{len .Packages}}</td><td>{{.Total.Functions}}</td><td>{{.Total.Pointers}}</td><td>{{.Total.Uses}}</td><td>{{.Total.GuardedUses}}</td><td>{{.Total.Coverage}}</td><td>{{.Total.Findings}}</td><td>{{.Total.Suppressed}}</td></tr>
+</table>
+
+<h2>Packages</h2>
+<table>
+<tr><th>Package / function</th><th>Location</th><th>Pointers</th><th>Uses</th><th>Guarded uses</th><th>Coverage</th><th>Findings</th><th>Suppressed</th></tr>
+{{- range .Packages}}
+<tr class="package"><td><strong>{{.Path}}</strong></td><td></td><td>{{.Pointers}}</td><td>{{.Uses}}</td><td>{{.GuardedUses}}</td><td>{{.Coverage}}</td><td>{{.Findings}}</td><td>{{.Suppressed}}</td></tr>
+{{- range .Funcs}}
+<tr class="func"><td>{{.Name}}</td><td class="loc">{{.Location}}</td><td>{{.Pointers}}</td><td>{{.Uses}}</td><td>{{.GuardedUses}}</td><td>{{.Coverage}}</td><td>{{.Findings}}</td><td>{{.Suppressed}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+
+<h2>Findings ({{len .Findings}})</h2>
+{{- range .Findings}}
+<div class="finding">
+<p><span class="{{.Severity}}">{{.Severity}}</span> <strong>{{.Rule}}</strong> <span class="loc">{{.Location}}</span>{{if .Func}} in {{.Func}}{{end}}</p>
+<p>{{.Message}}</p>
+{{- range .Related}}
+<p class="related"><span class="loc">{{.Location}}</span>: {{.Message}}</p>
+{{- end}}
+{{- if .Excerpt}}
+<pre>{{range .Excerpt}}<span{{if .Current}} class="current"{{end}}>{{printf "%5d" .Number}}  {{.Text}}</span>
+{{end}}</pre>
+{{- end}}
+</div>
+{{- else}}
+<p>No findings.</p>
+{{- end}}
+
+<h2>Suppressions ({{len .Suppressed}})</h2>
+{{- if .Suppressed}}
+<table>
+<tr><th>Location</th><th>Rule</th><th>Suppressed by</th><th>Reason</th><th>Message</th></tr>
+{{- range .Suppressed}}
+<tr><td class="loc">{{.Location}}</td><td>{{.Rule}}</td><td>{{if .Directive}}{{.Directive}}{{else}}{{.Kind}}{{end}}</td><td>{{if .Reason}}{{.Reason}}{{else}}<em>none given</em>{{end}}</td><td style="text-align: left">{{.Message}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No suppressed findings.</p>
+{{- end}}
+</body>
+</html>
+`))
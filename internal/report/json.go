@@ -0,0 +1,162 @@
+package report
+
+import (
+	"encoding/json"
+	"go/token"
+	"io"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// jsonVersion is the version of the JSON output schema. It changes whenever
+// a field is removed or changes meaning; new fields may be added without a
+// version change.
+const jsonVersion = 1
+
+// stateActive is the suppression state of a finding that is reported. The
+// other states are the driver's Suppression kinds.
+const stateActive = "active"
+
+// The json* types define the JSON output schema; see WriteJSON.
+type (
+	jsonLog struct {
+		Version  int           `json:"version"`
+		Findings []jsonFinding `json:"findings"`
+	}
+
+	jsonFinding struct {
+		Fingerprint    string          `json:"fingerprint"`
+		Rule           string          `json:"rule"`
+		Severity       string          `json:"severity"`
+		Message        string          `json:"message"`
+		Package        string          `json:"package"`
+		Func           string          `json:"func"`
+		Pointer        string          `json:"pointer"`
+		PointerType    string          `json:"pointer_type"`
+		UseKind        string          `json:"use_kind"`
+		Position       jsonPosition    `json:"position"`
+		End            *jsonPosition   `json:"end,omitempty"`
+		Related        []jsonRelated   `json:"related"`
+		SuggestedFixes []jsonFix       `json:"suggested_fixes"`
+		Suppression    jsonSuppression `json:"suppression"`
+	}
+
+	jsonPosition struct {
+		File   string `json:"file"`
+		Line   int    `json:"line"`
+		Column int    `json:"column"`
+		Offset int    `json:"offset"`
+	}
+
+	jsonRelated struct {
+		Position jsonPosition `json:"position"`
+		Message  string       `json:"message"`
+	}
+
+	jsonFix struct {
+		Message string     `json:"message"`
+		Edits   []jsonEdit `json:"edits"`
+	}
+
+	jsonEdit struct {
+		Position jsonPosition `json:"position"`
+		End      jsonPosition `json:"end"`
+		NewText  string       `json:"new_text"`
+	}
+
+	jsonSuppression struct {
+		State     string `json:"state"`
+		Directive string `json:"directive,omitempty"`
+		Reason    string `json:"reason,omitempty"`
+	}
+)
+
+// WriteJSON writes findings as a single JSON object:
+//
+//	{
+//	  "version": 1,
+//	  "findings": [{
+//	    "fingerprint": "3f1c...",          // see Fingerprints; survives line shifts
+//	    "rule": "unchecked",               // analyzer.Rules
+//	    "severity": "warning",             // error, warning or note
+//	    "message": "pointer \"p\" is used ...",
+//	    "package": "example.com/pkg",
+//	    "func": "(*T).M",                  // "" for suppression directives
+//	    "pointer": "p",                    // "" for suppression directives
+//	    "pointer_type": "*S",
+//	    "use_kind": "field",               // dereference, field or method
+//	    "position": {"file": "pkg/a.go", "line": 10, "column": 2, "offset": 120},
+//	    "end": {...},                      // only for diagnostics with an extent
+//	    "related": [{"position": {...}, "message": "p declared here"}],
+//	    "suggested_fixes": [{
+//	      "message": "Add nil guard for p",
+//	      "edits": [{"position": {...}, "end": {...}, "new_text": "..."}]
+//	    }],
+//	    "suppression": {
+//	      "state": "active",               // active, directive or baseline
+//	      "directive": "//nolint:nilguard", // for state directive
+//	      "reason": "..."                  // the directive's reason, if any
+//	    }
+//	  }]
+//	}
+//
+// File names are relative to root when they lie below it. Findings keep
+// their order. The fingerprint (see Fingerprints) identifies the finding
+// across line shifts. Lists are never null.
+func WriteJSON(w io.Writer, findings []driver.Finding, root string) error {
+	log := jsonLog{Version: jsonVersion, Findings: make([]jsonFinding, 0, len(findings))}
+	fps := Fingerprints(findings, root)
+	for i, f := range findings {
+		jf := jsonFinding{
+			Fingerprint:    fps[i],
+			Rule:           f.Rule,
+			Severity:       f.Severity,
+			Message:        f.Message,
+			Package:        f.Package,
+			Func:           f.Func,
+			Pointer:        f.Pointer,
+			PointerType:    f.PointerType,
+			UseKind:        f.UseKind,
+			Position:       jsonPos(root, f.Pos),
+			Related:        []jsonRelated{},
+			SuggestedFixes: []jsonFix{},
+			Suppression:    jsonSuppression{State: stateActive},
+		}
+		if f.End.IsValid() {
+			end := jsonPos(root, f.End)
+			jf.End = &end
+		}
+		for _, r := range f.Related {
+			jf.Related = append(jf.Related, jsonRelated{Position: jsonPos(root, r.Pos), Message: r.Message})
+		}
+		for _, fix := range f.Fixes {
+			jfix := jsonFix{Message: fix.Message, Edits: []jsonEdit{}}
+			for _, e := range fix.Edits {
+				jfix.Edits = append(jfix.Edits, jsonEdit{
+					Position: jsonPos(root, e.Pos),
+					End:      jsonPos(root, e.End),
+					NewText:  e.NewText,
+				})
+			}
+			jf.SuggestedFixes = append(jf.SuggestedFixes, jfix)
+		}
+		if s := f.Suppression; s != nil {
+			jf.Suppression = jsonSuppression{State: s.Kind, Directive: s.Directive, Reason: s.Reason}
+		}
+		log.Findings = append(log.Findings, jf)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// jsonPos converts pos, making its file name relative to root.
+func jsonPos(root string, pos token.Position) jsonPosition {
+	return jsonPosition{
+		File:   relPath(root, pos.Filename),
+		Line:   pos.Line,
+		Column: pos.Column,
+		Offset: pos.Offset,
+	}
+}
@@ -0,0 +1,84 @@
+// Package report renders driver Findings in the output formats supported by
+// cmd/nilguard.
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// WriteText writes findings in the classic vet style, one per line, each
+// followed by its related locations on indented lines:
+//
+//	file.go:12:6: pointer "p" is used in this function but never nil-checked (parameter, type *S)
+//		file.go:11:8: p declared here
+func WriteText(w io.Writer, findings []driver.Finding) error {
+	return WriteTextContext(w, findings, -1)
+}
+
+// WriteTextContext is like WriteText, but if context is not negative it also
+// prints the line of each finding with context lines before and after it,
+// each prefixed with its number, as the -c flag of singlechecker does. Lines
+// of files that cannot be read are omitted.
+func WriteTextContext(w io.Writer, findings []driver.Finding, context int) error {
+	sources := make(map[string][]string)
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", f.Pos, f.Message); err != nil {
+			return err
+		}
+		if context >= 0 {
+			lines := sourceLines(sources, f.Pos.Filename)
+			for n := max(1, f.Pos.Line-context); n <= min(len(lines), f.Pos.Line+context); n++ {
+				if _, err := fmt.Fprintf(w, "%d\t%s\n", n, lines[n-1]); err != nil {
+					return err
+				}
+			}
+		}
+		for _, r := range f.Related {
+			if _, err := fmt.Fprintf(w, "\t%s: %s\n", r.Pos, r.Message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sourceLines returns the lines of the file filename, reading and caching it
+// in sources, or nil if it cannot be read.
+func sourceLines(sources map[string][]string, filename string) []string {
+	lines, ok := sources[filename]
+	if !ok {
+		if data, err := os.ReadFile(filename); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		sources[filename] = lines
+	}
+	return lines
+}
+
+// relPath returns filename relative to root using forward slashes, or the
+// cleaned path if filename is not below root.
+func relPath(root, filename string) string {
+	if rel, ok := underRoot(root, filename); ok {
+		return rel
+	}
+	return filepath.ToSlash(filepath.Clean(filename))
+}
+
+// underRoot returns filename relative to root using forward slashes, and
+// whether filename lies below root at all.
+func underRoot(root, filename string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, filename)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,555 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// sample returns a finding in /repo/pkg/a.go at the given line.
+func sample(line int) driver.Finding {
+	return driver.Finding{
+		Rule:     analyzer.RuleNilAssign,
+		Message:  `pointer "p" is used after being set to nil`,
+		Severity: analyzer.SeverityError,
+		Package:  "example.com/pkg",
+		Func:     "(*T).M",
+		Pointer:  "p",
+		UseKind:  analyzer.UseField,
+		Pos:      token.Position{Filename: "/repo/pkg/a.go", Line: line, Column: 2},
+		Related: []driver.Related{{
+			Pos:     token.Position{Filename: "/repo/pkg/a.go", Line: line - 1, Column: 2},
+			Message: "set to nil here",
+		}},
+	}
+}
+
+// TestWriteText verifies the vet-style text output, including related
+// locations.
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, []driver.Finding{sample(10)}); err != nil {
+		t.Fatal(err)
+	}
+	want := "/repo/pkg/a.go:10:2: pointer \"p\" is used after being set to nil\n" +
+		"\t/repo/pkg/a.go:9:2: set to nil here\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+// TestWriteTrace verifies the text rendering of explain traces.
+func TestWriteTrace(t *testing.T) {
+	pos := func(line, col int) token.Position {
+		return token.Position{Filename: "/repo/a.go", Line: line, Column: col}
+	}
+	traces := []driver.Trace{{
+		Func:      "F",
+		FuncStart: pos(3, 1),
+		Events: []driver.TraceEvent{
+			{Pos: pos(4, 5), Pointer: "p", Kind: analyzer.TraceRejected, Message: "rejected guard: if p == nil: then-branch does not exit"},
+			{Pos: pos(7, 6), Pointer: "p", Kind: analyzer.TraceUse, Message: "use p.X"},
+		},
+	}, {
+		Func:      "G",
+		FuncStart: pos(10, 1),
+	}}
+	var buf bytes.Buffer
+	if err := WriteTrace(&buf, traces); err != nil {
+		t.Fatal(err)
+	}
+	want := "F at /repo/a.go:3:1\n" +
+		"\t/repo/a.go:4:5: p: rejected guard: if p == nil: then-branch does not exit\n" +
+		"\t/repo/a.go:7:6: p: use p.X\n" +
+		"G at /repo/a.go:10:1\n" +
+		"\tno pointer uses or nil-checks\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteTrace:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+// inFunc returns f moved into a function starting at line start.
+func inFunc(f driver.Finding, start int) driver.Finding {
+	f.FuncStart = token.Position{Filename: f.Pos.Filename, Line: start, Column: 1}
+	return f
+}
+
+// TestFingerprintIgnoresPosition verifies that fingerprints survive line
+// shifts but distinguish pointers and the uses of a pointer.
+func TestFingerprintIgnoresPosition(t *testing.T) {
+	a, b := inFunc(sample(10), 5), inFunc(sample(42), 37)
+	if Fingerprint(a, "/repo") != Fingerprint(b, "/repo") {
+		t.Errorf("fingerprint changed with line number")
+	}
+	b.Pointer = "q"
+	if Fingerprint(a, "/repo") == Fingerprint(b, "/repo") {
+		t.Errorf("fingerprint ignores pointer name")
+	}
+
+	// Another use of p in the same function.
+	if Fingerprint(a, "/repo") == Fingerprint(inFunc(sample(12), 5), "/repo") {
+		t.Errorf("fingerprint ignores the line of the use")
+	}
+	c := a
+	c.UseKind = analyzer.UseMethod
+	if Fingerprint(a, "/repo") == Fingerprint(c, "/repo") {
+		t.Errorf("fingerprint ignores the kind of use")
+	}
+
+	// Directives in the same file are told apart by their text.
+	d1 := driver.Finding{
+		Rule:    analyzer.RuleUnusedSuppression,
+		Message: "suppression directive //nilguard:ignore p does not suppress any diagnostic",
+		Pos:     token.Position{Filename: "/repo/pkg/a.go", Line: 3, Column: 1},
+	}
+	d2 := d1
+	d2.Message = "suppression directive //nilguard:ignore q does not suppress any diagnostic"
+	d2.Pos.Line = 20
+	if Fingerprint(d1, "/repo") == Fingerprint(d2, "/repo") {
+		t.Errorf("fingerprint ignores the directive")
+	}
+	d2.Message = d1.Message
+	if Fingerprint(d1, "/repo") != Fingerprint(d2, "/repo") {
+		t.Errorf("directive fingerprint changed with line number")
+	}
+}
+
+// TestFingerprintsRepeated verifies that identical findings, such as two
+// unused directives with the same text in one file, get distinct
+// fingerprints in every report format.
+func TestFingerprintsRepeated(t *testing.T) {
+	d := driver.Finding{
+		Rule:    analyzer.RuleUnusedSuppression,
+		Message: "suppression directive //nolint:nilguard does not suppress any diagnostic",
+		Pos:     token.Position{Filename: "/repo/pkg/a.go", Line: 3, Column: 1},
+	}
+	d2 := d
+	d2.Pos.Line = 7
+	findings := []driver.Finding{d, d2}
+
+	fps := Fingerprints(findings, "/repo")
+	if fps[0] != Fingerprint(d, "/repo") {
+		t.Errorf("first fingerprint = %s, want the finding's Fingerprint", fps[0])
+	}
+	if fps[0] == fps[1] {
+		t.Errorf("identical directives share fingerprint %s", fps[0])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGitLab(&buf, findings, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	var issues []gitlabIssue
+	if err := json.Unmarshal(buf.Bytes(), &issues); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(issues) != 2 || issues[0].Fingerprint != fps[0] || issues[1].Fingerprint != fps[1] {
+		t.Errorf("GitLab issues = %+v, want fingerprints %q", issues, fps)
+	}
+
+	buf.Reset()
+	if err := WriteSARIF(&buf, analyzer.Analyzer, findings, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for i, res := range log.Runs[0].Results {
+		if got := res.PartialFingerprints[fingerprintKey]; got != fps[i] {
+			t.Errorf("SARIF result %d fingerprint = %s, want %s", i, got, fps[i])
+		}
+	}
+}
+
+// TestWriteSARIF checks the essential shape of the SARIF log.
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, analyzer.Analyzer, []driver.Finding{sample(10)}, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log header: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(analyzer.Rules) {
+		t.Errorf("got %d rules, want %d", len(run.Tool.Driver.Rules), len(analyzer.Rules))
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(run.Results))
+	}
+
+	res := run.Results[0]
+	if got := run.Tool.Driver.Rules[res.RuleIndex].ID; got != res.RuleID {
+		t.Errorf("ruleIndex points at %q, want %q", got, res.RuleID)
+	}
+	if res.Level != analyzer.SeverityError {
+		t.Errorf("level = %q, want the finding's severity %q", res.Level, analyzer.SeverityError)
+	}
+	art := res.Locations[0].PhysicalLocation.ArtifactLocation
+	if art.URI != "pkg/a.go" || art.URIBaseID != "%SRCROOT%" {
+		t.Errorf("artifact location = %+v, want pkg/a.go relative to %%SRCROOT%%", art)
+	}
+	if got := res.Locations[0].LogicalLocations[0].FullyQualifiedName; got != "example.com/pkg.(*T).M" {
+		t.Errorf("logical location = %q", got)
+	}
+	if len(res.RelatedLocations) != 1 || res.RelatedLocations[0].PhysicalLocation.Region.StartLine != 9 {
+		t.Errorf("related locations = %+v", res.RelatedLocations)
+	}
+	if res.PartialFingerprints[fingerprintKey] == "" {
+		t.Errorf("missing %s fingerprint", fingerprintKey)
+	}
+}
+
+// TestWriteJSON verifies the documented JSON schema, including suppression
+// state and suggested fixes.
+func TestWriteJSON(t *testing.T) {
+	active := sample(10)
+	active.PointerType, active.UseKind = "*S", analyzer.UseField
+	active.Fixes = []driver.Fix{{
+		Message: "Add nil guard for p",
+		Edits: []driver.Edit{{
+			Pos:     token.Position{Filename: "/repo/pkg/a.go", Line: 10, Column: 2},
+			End:     token.Position{Filename: "/repo/pkg/a.go", Line: 10, Column: 2},
+			NewText: "if p == nil {\n\treturn\n}\n",
+		}},
+	}}
+	suppressed := sample(20)
+	suppressed.Suppression = &driver.Suppression{
+		Kind:      driver.SuppressedByDirective,
+		Directive: "//nolint:nilguard",
+		Reason:    "checked by caller",
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, []driver.Finding{active, suppressed}, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Version  int
+		Findings []map[string]any
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Version != 1 || len(got.Findings) != 2 {
+		t.Fatalf("got version %d with %d findings, want version 1 with 2", got.Version, len(got.Findings))
+	}
+
+	f := got.Findings[0]
+	for key, want := range map[string]any{
+		"fingerprint":  Fingerprint(active, "/repo"),
+		"rule":         analyzer.RuleNilAssign,
+		"severity":     analyzer.SeverityError,
+		"package":      "example.com/pkg",
+		"func":         "(*T).M",
+		"pointer":      "p",
+		"pointer_type": "*S",
+		"use_kind":     "field",
+		"suppression":  map[string]any{"state": "active"},
+	} {
+		if fmt.Sprint(f[key]) != fmt.Sprint(want) {
+			t.Errorf("%s = %v, want %v", key, f[key], want)
+		}
+	}
+	if pos := f["position"].(map[string]any); pos["file"] != "pkg/a.go" || pos["line"] != 10.0 {
+		t.Errorf("position = %v, want pkg/a.go line 10", pos)
+	}
+	if fixes := f["suggested_fixes"].([]any); len(fixes) != 1 {
+		t.Errorf("suggested_fixes = %v, want 1 fix", fixes)
+	}
+
+	want := map[string]any{"state": "directive", "directive": "//nolint:nilguard", "reason": "checked by caller"}
+	if s := got.Findings[1]["suppression"]; fmt.Sprint(s) != fmt.Sprint(want) {
+		t.Errorf("suppression = %v, want %v", s, want)
+	}
+	if fixes := got.Findings[1]["suggested_fixes"].([]any); len(fixes) != 0 {
+		t.Errorf("suggested_fixes = %v, want an empty list", fixes)
+	}
+}
+
+// TestWriteGitHub verifies the workflow command syntax and escaping.
+func TestWriteGitHub(t *testing.T) {
+	f := sample(10)
+	f.Message = "100% nil,\nsurely"
+	var buf bytes.Buffer
+	if err := WriteGitHub(&buf, []driver.Finding{f}, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	want := "::error file=pkg/a.go,line=10,col=2,title=nilguard (nil-assign)::100%25 nil,%0Asurely%0Apkg/a.go:9:2: set to nil here\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestWriteGitLab verifies the Code Quality fields and that two uses of a
+// pointer in one function have distinct fingerprints.
+func TestWriteGitLab(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGitLab(&buf, []driver.Finding{inFunc(sample(10), 5), inFunc(sample(12), 5)}, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	var issues []gitlabIssue
+	if err := json.Unmarshal(buf.Bytes(), &issues); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want 2", len(issues))
+	}
+	got := issues[0]
+	if got.CheckName != "nilguard/nil-assign" || got.Severity != "major" ||
+		got.Location.Path != "pkg/a.go" || got.Location.Lines.Begin != 10 {
+		t.Errorf("issue = %+v", got)
+	}
+	if got.Fingerprint != Fingerprint(inFunc(sample(10), 5), "/repo") {
+		t.Errorf("first fingerprint = %s, want the finding's Fingerprint", got.Fingerprint)
+	}
+	if issues[1].Fingerprint == got.Fingerprint {
+		t.Errorf("repeated fingerprint %s", got.Fingerprint)
+	}
+}
+
+// TestWriteCheckstyle verifies that findings are grouped by file.
+func TestWriteCheckstyle(t *testing.T) {
+	other := sample(3)
+	other.Pos.Filename = "/repo/pkg/b.go"
+	var buf bytes.Buffer
+	if err := WriteCheckstyle(&buf, []driver.Finding{sample(10), sample(12), other}, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	var log checkstyleLog
+	if err := xml.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if len(log.Files) != 2 || log.Files[0].Name != "pkg/a.go" || len(log.Files[0].Errors) != 2 || log.Files[1].Name != "pkg/b.go" {
+		t.Fatalf("files = %+v, want pkg/a.go with 2 errors and pkg/b.go", log.Files)
+	}
+	if e := log.Files[0].Errors[0]; e.Line != 10 || e.Severity != "error" || e.Source != "nilguard.nil-assign" {
+		t.Errorf("error = %+v", e)
+	}
+}
+
+// TestWriteJUnit verifies that every package is a test case and that only
+// packages with findings fail.
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	pkgs := []string{"example.com/clean", "example.com/pkg"}
+	if err := WriteJUnit(&buf, []driver.Finding{sample(10), sample(12)}, pkgs, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if suites.Tests != 2 || suites.Failures != 1 || len(suites.Suites) != 1 {
+		t.Fatalf("suites = %+v, want 2 tests with 1 failure in one suite", suites)
+	}
+	cases := suites.Suites[0].Cases
+	if cases[0].Name != "example.com/clean" || cases[0].Failure != nil {
+		t.Errorf("clean package = %+v, want a passing test case", cases[0])
+	}
+	if f := cases[1].Failure; f == nil || f.Message != "2 nilguard findings" || strings.Count(f.Text, "\n") != 2 {
+		t.Errorf("failing package = %+v, want 2 findings", cases[1])
+	}
+}
+
+// TestWriteHTML verifies the totals, source excerpts and suppressions of the
+// HTML report.
+func TestWriteHTML(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(src, []byte("package a\n\nfunc F(p *S) int {\n\treturn p.X\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fn := token.Position{Filename: src, Line: 3, Column: 1}
+	active := driver.Finding{
+		Rule:      analyzer.RuleUnchecked,
+		Message:   `pointer "p" is used <unchecked>`,
+		Severity:  analyzer.SeverityWarning,
+		Package:   "example.com/a",
+		Func:      "F",
+		FuncStart: fn,
+		Pos:       token.Position{Filename: src, Line: 4, Column: 9},
+	}
+	silenced := active
+	silenced.Pos.Line = 5
+	silenced.Suppression = &driver.Suppression{Kind: driver.SuppressedByDirective, Directive: "//nolint:nilguard", Reason: "checked by caller"}
+	stats := []driver.FuncStats{{Package: "example.com/a", Func: "F", FuncStart: fn, Pointers: 1, Uses: 4, GuardedUses: 1}}
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, []driver.Finding{active, silenced}, stats, []string{"example.com/a", "example.com/clean"}, dir); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"<strong>example.com/clean</strong>",
+		"<td>F</td><td class=\"loc\">a.go:3</td><td>1</td><td>4</td><td>1</td><td>25.0%</td><td>1</td><td>1</td>",
+		"a.go:4:9",
+		"&lt;unchecked&gt;",
+		`<span class="current">    4  	return p.X</span>`,
+		"checked by caller",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML report lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<unchecked>") {
+		t.Error("HTML report does not escape messages")
+	}
+}
+
+// sampleStats returns statistics for two functions of example.com/pkg.
+func sampleStats() []driver.FuncStats {
+	return []driver.FuncStats{
+		{Package: "example.com/pkg", Func: "F", FuncStart: token.Position{Filename: "/repo/pkg/a.go", Line: 10, Column: 1},
+			Pointers: 1, Uses: 2, GuardedUses: 2, TrustedUses: 1},
+		{Package: "example.com/pkg", Func: "(*T).M", FuncStart: token.Position{Filename: "/repo/pkg/a.go", Line: 20, Column: 1},
+			Pointers: 2, Uses: 3, GuardedUses: 1, SuppressedUses: 1},
+	}
+}
+
+// TestWriteStats verifies the statistics table, including packages without
+// pointer uses and the total.
+func TestWriteStats(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteStats(&buf, sampleStats(), []string{"example.com/clean", "example.com/pkg"}, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		got = append(got, strings.Join(strings.Fields(line), " "))
+	}
+	want := []string{
+		"PACKAGE / FUNCTION POINTERS USES GUARDED SUPPRESSED TRUSTED COVERAGE",
+		"example.com/clean 0 0 0 0 0 -",
+		"example.com/pkg 3 5 3 1 1 60.0%",
+		"F (pkg/a.go:10) 1 2 2 0 1 100.0%",
+		"(*T).M (pkg/a.go:20) 2 3 1 1 0 33.3%",
+		"total 3 5 3 1 1 60.0%",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("WriteStats:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+// TestWriteStatsJSON verifies the JSON statistics schema.
+func TestWriteStatsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteStatsJSON(&buf, sampleStats(), []string{"example.com/clean", "example.com/pkg"}, "/repo"); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version int
+		Total   struct {
+			FunctionCount int      `json:"function_count"`
+			GuardedUses   int      `json:"guarded_uses"`
+			Coverage      *float64 `json:"coverage"`
+		}
+		Packages []struct {
+			Package       string
+			FunctionCount int `json:"function_count"`
+			Coverage      *float64
+			Functions     []struct {
+				Func     string
+				Position struct{ File string }
+				Coverage float64
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if log.Version != 1 || log.Total.FunctionCount != 2 || log.Total.GuardedUses != 3 || log.Total.Coverage == nil || *log.Total.Coverage != 60 {
+		t.Errorf("total = %+v, want 2 functions, 3 guarded uses and 60%% coverage", log.Total)
+	}
+	if len(log.Packages) != 2 || log.Packages[0].Coverage != nil || len(log.Packages[0].Functions) != 0 || log.Packages[0].FunctionCount != 0 {
+		t.Fatalf("packages = %+v, want example.com/clean without functions or coverage first", log.Packages)
+	}
+	if n := log.Packages[1].FunctionCount; n != 2 {
+		t.Errorf("function_count of example.com/pkg = %d, want 2", n)
+	}
+	if fs := log.Packages[1].Functions; len(fs) != 2 || fs[0].Func != "F" || fs[0].Position.File != "pkg/a.go" || fs[0].Coverage != 100 {
+		t.Errorf("functions = %+v", fs)
+	}
+}
+
+// TestWriteDiff verifies the hunks of a unified diff: an insertion, a
+// replacement on the same line merged into one region, and a distant edit
+// in a hunk of its own, at the end of a file without a final newline.
+func TestWriteDiff(t *testing.T) {
+	src := "package m\n\nfunc F(p *S) int {\n\treturn p.X\n}\n\n\n\n\n\n\n\nfunc G() {}"
+	at := func(s string) token.Position {
+		return token.Position{Filename: "m.go", Offset: strings.Index(src, s)}
+	}
+	end := func(s string) token.Position {
+		p := at(s)
+		p.Offset += len(s)
+		return p
+	}
+	edits := []driver.Edit{
+		{Pos: at("return p.X"), End: at("return p.X"), NewText: "if p == nil {\n\t\treturn 0\n\t}\n\t"},
+		{Pos: at("X\n}"), End: end("X"), NewText: "Y"},
+		{Pos: at("G()"), End: end("G()"), NewText: "H()"},
+	}
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, "m.go", []byte(src), edits); err != nil {
+		t.Fatal(err)
+	}
+	want := `--- m.go (old)
++++ m.go (new)
+@@ -1,7 +1,10 @@
+ package m
+ 
+ func F(p *S) int {
+-	return p.X
++	if p == nil {
++		return 0
++	}
++	return p.Y
+ }
+ 
+ 
+@@ -10,4 +13,4 @@
+ 
+ 
+ 
+-func G() {}
+\ No newline at end of file
++func H() {}
+\ No newline at end of file
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteDiff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestWriteTextContext verifies the source lines printed around a finding.
+func TestWriteTextContext(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(name, []byte("package a\n\nvar x = p.X\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := driver.Finding{Message: "msg", Pos: token.Position{Filename: name, Line: 3, Column: 9}}
+	var buf bytes.Buffer
+	if err := WriteTextContext(&buf, []driver.Finding{f}, 1); err != nil {
+		t.Fatal(err)
+	}
+	want := name + ":3:9: msg\n2\t\n3\tvar x = p.X\n4\t\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteTextContext:\ngot  %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/HMetcalfe/nilguard/internal/driver"
+	"golang.org/x/tools/go/analysis"
+)
+
+// SARIF 2.1.0 constants.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifRootID  = "%SRCROOT%"
+	toolURI      = "https://github.com/HMetcalfe/nilguard"
+
+	// fingerprintKey names the partial fingerprint nilguard contributes. The
+	// version suffix must change whenever the fingerprint inputs change.
+	fingerprintKey = "nilguard/v1"
+)
+
+// The sarif* types model the subset of the SARIF 2.1.0 object model that
+// nilguard emits.
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool               sarifTool                   `json:"tool"`
+		OriginalURIBaseIDs map[string]sarifArtifactLoc `json:"originalUriBaseIds,omitempty"`
+		Results            []sarifResult               `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		ID                   string        `json:"id"`
+		ShortDescription     sarifMessage  `json:"shortDescription"`
+		FullDescription      sarifMessage  `json:"fullDescription"`
+		HelpURI              string        `json:"helpUri"`
+		DefaultConfiguration sarifRuleConf `json:"defaultConfiguration"`
+	}
+
+	sarifRuleConf struct {
+		Level string `json:"level"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifResult struct {
+		RuleID              string            `json:"ruleId"`
+		RuleIndex           int               `json:"ruleIndex"`
+		Level               string            `json:"level"`
+		Message             sarifMessage      `json:"message"`
+		Locations           []sarifLocation   `json:"locations"`
+		RelatedLocations    []sarifLocation   `json:"relatedLocations,omitempty"`
+		PartialFingerprints map[string]string `json:"partialFingerprints"`
+	}
+
+	sarifLocation struct {
+		ID               *int                  `json:"id,omitempty"`
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+		LogicalLocations []sarifLogicalLoc     `json:"logicalLocations,omitempty"`
+		Message          *sarifMessage         `json:"message,omitempty"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLoc `json:"artifactLocation"`
+		Region           sarifRegion      `json:"region"`
+	}
+
+	sarifArtifactLoc struct {
+		URI       string `json:"uri"`
+		URIBaseID string `json:"uriBaseId,omitempty"`
+	}
+
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+		EndLine     int `json:"endLine,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+	}
+
+	sarifLogicalLoc struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+		Kind               string `json:"kind"`
+	}
+)
+
+// WriteSARIF writes findings as a SARIF 2.1.0 log with a single run.
+//
+// Rule metadata is taken from analyzer.Rules, with a's Doc as the full
+// description. File locations are made relative to root (normally the
+// repository root) and expressed against the %SRCROOT% base, so that
+// code-scanning services can map them onto their checkout. Each result
+// carries a fingerprint (see Fingerprints) that survives line shifts, and the
+// level given by the finding's severity.
+func WriteSARIF(w io.Writer, a *analysis.Analyzer, findings []driver.Finding, root string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           a.Name,
+			InformationURI: toolURI,
+		}},
+		Results: make([]sarifResult, 0, len(findings)),
+	}
+	if root != "" {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLoc{
+			sarifRootID: {URI: fileURI(root) + "/"},
+		}
+	}
+
+	ruleIndex := make(map[string]int)
+	for i, r := range analyzer.Rules {
+		ruleIndex[r.ID] = i
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{Text: r.Summary},
+			FullDescription:      sarifMessage{Text: a.Doc},
+			HelpURI:              toolURI + "#what-it-detects",
+			DefaultConfiguration: sarifRuleConf{Level: "warning"},
+		})
+	}
+
+	fps := Fingerprints(findings, root)
+	for i, f := range findings {
+		loc := sarifLoc(root, f.Pos, f.End)
+		if f.Func != "" {
+			loc.LogicalLocations = []sarifLogicalLoc{{
+				FullyQualifiedName: f.Package + "." + f.Func,
+				Kind:               "function",
+			}}
+		}
+		level := f.Severity
+		if level == "" {
+			level = analyzer.SeverityWarning
+		}
+		res := sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: ruleIndex[f.Rule],
+			Level:     level,
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{loc},
+			PartialFingerprints: map[string]string{
+				fingerprintKey: fps[i],
+			},
+		}
+		for i, r := range f.Related {
+			id := i + 1
+			rel := sarifLoc(root, r.Pos, r.Pos)
+			rel.ID = &id
+			rel.Message = &sarifMessage{Text: r.Message}
+			res.RelatedLocations = append(res.RelatedLocations, rel)
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifLoc builds a physical location for the region [pos, end].
+func sarifLoc(root string, pos, end token.Position) sarifLocation {
+	art := sarifArtifactLoc{URI: fileURI(pos.Filename)}
+	if rel, ok := underRoot(root, pos.Filename); ok {
+		art = sarifArtifactLoc{URI: escapePath(rel), URIBaseID: sarifRootID}
+	}
+	region := sarifRegion{StartLine: pos.Line, StartColumn: pos.Column}
+	if end.IsValid() && end.Filename == pos.Filename {
+		region.EndLine = end.Line
+		region.EndColumn = end.Column
+	}
+	return sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: art,
+		Region:           region,
+	}}
+}
+
+// Fingerprint returns a stable identifier for f that survives line shifts: a
+// hash of the rule, the file path relative to root, the enclosing function,
+// the pointer name and the use. A use is identified by its kind and its
+// position relative to the start of the function, so that every use of a
+// pointer has its own fingerprint, while a finding about a directive is
+// identified by its message, which quotes the directive.
+func Fingerprint(f driver.Finding, root string) string {
+	use := f.Message
+	if f.Func != "" {
+		use = fmt.Sprintf("%s@%d:%d", f.UseKind, f.Pos.Line-f.FuncStart.Line, f.Pos.Column)
+	}
+	key := strings.Join([]string{f.Rule, relPath(root, f.Pos.Filename), f.Func, f.Pointer, use}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Fingerprints returns the Fingerprint of each of findings, made unique by
+// combining every repeated fingerprint with its rank among the findings
+// sharing it, in the order of findings. Identical findings, such as two
+// unused //nolint:nilguard directives in one file, thus keep distinct
+// identifiers, which code-scanning services and GitLab require. The first
+// occurrence keeps its plain Fingerprint.
+func Fingerprints(findings []driver.Finding, root string) []string {
+	fps := make([]string, len(findings))
+	seen := make(map[string]int)
+	for i, f := range findings {
+		fp := Fingerprint(f, root)
+		seen[fp]++
+		if n := seen[fp]; n > 1 {
+			sum := sha256.Sum256([]byte(fp + "\x00" + strconv.Itoa(n)))
+			fp = hex.EncodeToString(sum[:16])
+		}
+		fps[i] = fp
+	}
+	return fps
+}
+
+// fileURI returns the file:// URI for an absolute path.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive letters
+	}
+	return "file://" + escapePath(path)
+}
+
+// escapePath percent-encodes each segment of a slash-separated path.
+func escapePath(path string) string {
+	return (&url.URL{Path: path}).EscapedPath()
+}
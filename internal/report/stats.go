@@ -0,0 +1,177 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// statsVersion is the version of the JSON statistics schema, which changes
+// as jsonVersion does.
+const statsVersion = 1
+
+// statsCounts are the totals of the statistics for a package or the whole
+// report.
+type statsCounts struct {
+	FunctionCount  int      `json:"function_count"`
+	Pointers       int      `json:"pointers"`
+	Uses           int      `json:"uses"`
+	GuardedUses    int      `json:"guarded_uses"`
+	SuppressedUses int      `json:"suppressed_uses"`
+	TrustedUses    int      `json:"trusted_uses"`
+	Coverage       *float64 `json:"coverage"`
+}
+
+// add adds the counts of s to c.
+func (c *statsCounts) add(s driver.FuncStats) {
+	c.FunctionCount++
+	c.Pointers += s.Pointers
+	c.Uses += s.Uses
+	c.GuardedUses += s.GuardedUses
+	c.SuppressedUses += s.SuppressedUses
+	c.TrustedUses += s.TrustedUses
+	c.Coverage = coverage(c.GuardedUses, c.Uses)
+}
+
+// coverage returns the percentage of guarded uses, or nil if there are no
+// uses.
+func coverage(guarded, uses int) *float64 {
+	if uses == 0 {
+		return nil
+	}
+	c := 100 * float64(guarded) / float64(uses)
+	return &c
+}
+
+// formatCoverage formats c for text output.
+func formatCoverage(c *float64) string {
+	if c == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", *c)
+}
+
+// The stats* types define the JSON statistics schema; see WriteStatsJSON.
+type (
+	statsLog struct {
+		Version  int            `json:"version"`
+		Total    statsCounts    `json:"total"`
+		Packages []statsPackage `json:"packages"`
+	}
+
+	statsPackage struct {
+		Package string `json:"package"`
+		statsCounts
+		Functions []statsFunc `json:"functions"`
+	}
+
+	statsFunc struct {
+		Func           string       `json:"func"`
+		Position       jsonPosition `json:"position"`
+		Pointers       int          `json:"pointers"`
+		Uses           int          `json:"uses"`
+		GuardedUses    int          `json:"guarded_uses"`
+		SuppressedUses int          `json:"suppressed_uses"`
+		TrustedUses    int          `json:"trusted_uses"`
+		Coverage       *float64     `json:"coverage"`
+	}
+)
+
+// groupStats groups stats by package, in the order of pkgs followed by any
+// other packages in order of appearance, and totals them.
+func groupStats(stats []driver.FuncStats, pkgs []string, root string) statsLog {
+	log := statsLog{Version: statsVersion, Packages: []statsPackage{}}
+	index := make(map[string]int)
+	pkg := func(path string) *statsPackage {
+		i, ok := index[path]
+		if !ok {
+			i = len(log.Packages)
+			index[path] = i
+			log.Packages = append(log.Packages, statsPackage{Package: path, Functions: []statsFunc{}})
+		}
+		return &log.Packages[i]
+	}
+	for _, path := range pkgs {
+		pkg(path)
+	}
+	for _, s := range stats {
+		p := pkg(s.Package)
+		p.statsCounts.add(s)
+		log.Total.add(s)
+		p.Functions = append(p.Functions, statsFunc{
+			Func:           s.Func,
+			Position:       jsonPos(root, s.FuncStart),
+			Pointers:       s.Pointers,
+			Uses:           s.Uses,
+			GuardedUses:    s.GuardedUses,
+			SuppressedUses: s.SuppressedUses,
+			TrustedUses:    s.TrustedUses,
+			Coverage:       coverage(s.GuardedUses, s.Uses),
+		})
+	}
+	return log
+}
+
+// WriteStats writes stats as a table with one row per package, followed by
+// one indented row per function of the package and a final total:
+//
+//	PACKAGE / FUNCTION  POINTERS  USES  GUARDED  SUPPRESSED  TRUSTED  COVERAGE
+//	example.com/pkg     3         5     4        1           1        80.0%
+//	  F (a.go:10)       1         2     2        0           0        100.0%
+//
+// Coverage is the share of uses that are guarded. Every package of pkgs is
+// listed. File names are relative to root.
+func WriteStats(w io.Writer, stats []driver.FuncStats, pkgs []string, root string) error {
+	log := groupStats(stats, pkgs, root)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	row := func(name string, c statsCounts) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			name, c.Pointers, c.Uses, c.GuardedUses, c.SuppressedUses, c.TrustedUses, formatCoverage(c.Coverage))
+	}
+	fmt.Fprintf(tw, "PACKAGE / FUNCTION\tPOINTERS\tUSES\tGUARDED\tSUPPRESSED\tTRUSTED\tCOVERAGE\n")
+	for _, p := range log.Packages {
+		row(p.Package, p.statsCounts)
+		for _, f := range p.Functions {
+			row(fmt.Sprintf("  %s (%s:%d)", f.Func, f.Position.File, f.Position.Line), statsCounts{
+				Pointers:       f.Pointers,
+				Uses:           f.Uses,
+				GuardedUses:    f.GuardedUses,
+				SuppressedUses: f.SuppressedUses,
+				TrustedUses:    f.TrustedUses,
+				Coverage:       f.Coverage,
+			})
+		}
+	}
+	row("total", log.Total)
+	return tw.Flush()
+}
+
+// WriteStatsJSON writes stats as a single JSON object:
+//
+//	{
+//	  "version": 1,
+//	  "total": {"function_count": 12, "pointers": 20, "uses": 45, "guarded_uses": 40,
+//	            "suppressed_uses": 2, "trusted_uses": 6, "coverage": 88.9},
+//	  "packages": [{
+//	    "package": "example.com/pkg",
+//	    ...                                // the same counts as total
+//	    "functions": [{
+//	      "func": "(*T).M",
+//	      "position": {"file": "pkg/a.go", "line": 10, "column": 1, "offset": 120},
+//	      ...                              // the counts, without function_count
+//	    }]
+//	  }]
+//	}
+//
+// Coverage is the percentage of uses that are guarded, or null if there are
+// none. Suppressed uses are unguarded uses covered by a directive; trusted
+// uses are guarded uses that rely on trust rather than a nil check. Every
+// package of pkgs is listed. File names are relative to root.
+func WriteStatsJSON(w io.Writer, stats []driver.FuncStats, pkgs []string, root string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(groupStats(stats, pkgs, root))
+}
@@ -0,0 +1,34 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// WriteTrace writes traces as text, one block per function: a header naming
+// the function, then one indented line per event.
+//
+//	(*T).M at file.go:10:1
+//		file.go:11:6: p: use p.X
+//		file.go:12:5: p: rejected guard: if p == nil: then-branch does not exit
+//		file.go:11:6: p: unchecked: no guard was accepted, so the use is reported
+func WriteTrace(w io.Writer, traces []driver.Trace) error {
+	for _, t := range traces {
+		if _, err := fmt.Fprintf(w, "%s at %s\n", t.Func, t.FuncStart); err != nil {
+			return err
+		}
+		if len(t.Events) == 0 {
+			if _, err := fmt.Fprintf(w, "\tno pointer uses or nil-checks\n"); err != nil {
+				return err
+			}
+		}
+		for _, e := range t.Events {
+			if _, err := fmt.Fprintf(w, "\t%s: %s: %s\n", e.Pos, e.Pointer, e.Message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
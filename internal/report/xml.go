@@ -0,0 +1,167 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/HMetcalfe/nilguard/internal/driver"
+)
+
+// checkstyleVersion is the checkstyle report format version that
+// WriteCheckstyle emits, as accepted by the Jenkins Warnings plugin and
+// similar consumers.
+const checkstyleVersion = "4.3"
+
+type (
+	checkstyleLog struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}
+
+	checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+
+	checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+)
+
+// WriteCheckstyle writes findings as a checkstyle XML report, with one <file>
+// element per file in order of first appearance and one <error> per finding.
+// The source attribute is "nilguard." followed by the rule, and severities
+// are error, warning or info. File names are relative to root.
+func WriteCheckstyle(w io.Writer, findings []driver.Finding, root string) error {
+	log := checkstyleLog{Version: checkstyleVersion}
+	index := make(map[string]int)
+	for _, f := range findings {
+		name := relPath(root, f.Pos.Filename)
+		i, ok := index[name]
+		if !ok {
+			i = len(log.Files)
+			index[name] = i
+			log.Files = append(log.Files, checkstyleFile{Name: name})
+		}
+		log.Files[i].Errors = append(log.Files[i].Errors, checkstyleError{
+			Line:     f.Pos.Line,
+			Column:   f.Pos.Column,
+			Severity: checkstyleSeverity(f.Severity),
+			Message:  f.Message,
+			Source:   "nilguard." + f.Rule,
+		})
+	}
+	return writeXML(w, log)
+}
+
+// checkstyleSeverity maps a finding's severity onto checkstyle's.
+func checkstyleSeverity(severity string) string {
+	switch severity {
+	case analyzer.SeverityError:
+		return "error"
+	case analyzer.SeverityNote:
+		return "info"
+	}
+	return "warning"
+}
+
+type (
+	junitSuites struct {
+		XMLName  xml.Name     `xml:"testsuites"`
+		Name     string       `xml:"name,attr"`
+		Tests    int          `xml:"tests,attr"`
+		Failures int          `xml:"failures,attr"`
+		Suites   []junitSuite `xml:"testsuite"`
+	}
+
+	junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Cases    []junitCase `xml:"testcase"`
+	}
+
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+	}
+
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// WriteJUnit writes findings as a JUnit XML report for CI gates. Each package
+// of pkgs is a test case of a single "nilguard" test suite; a package with
+// findings fails, with one line per finding in its failure text:
+//
+//	pkg/a.go:10:2: pointer "p" is used ... (unchecked)
+//
+// Findings in packages missing from pkgs get test cases of their own. File
+// names are relative to root.
+func WriteJUnit(w io.Writer, findings []driver.Finding, pkgs []string, root string) error {
+	pkgs = slices.Clone(pkgs)
+	byPkg := make(map[string][]driver.Finding)
+	for _, f := range findings {
+		if _, ok := byPkg[f.Package]; !ok && !slices.Contains(pkgs, f.Package) {
+			pkgs = append(pkgs, f.Package)
+		}
+		byPkg[f.Package] = append(byPkg[f.Package], f)
+	}
+
+	suite := junitSuite{Name: "nilguard"}
+	for _, pkg := range pkgs {
+		c := junitCase{Name: pkg, ClassName: "nilguard"}
+		if fs := byPkg[pkg]; len(fs) > 0 {
+			var text strings.Builder
+			for _, f := range fs {
+				fmt.Fprintf(&text, "%s:%d:%d: %s (%s)\n", relPath(root, f.Pos.Filename), f.Pos.Line, f.Pos.Column, f.Message, f.Rule)
+			}
+			noun := "findings"
+			if len(fs) == 1 {
+				noun = "finding"
+			}
+			c.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d nilguard %s", len(fs), noun),
+				Type:    "nilguard",
+				Text:    text.String(),
+			}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	return writeXML(w, junitSuites{
+		Name:     "nilguard",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitSuite{suite},
+	})
+}
+
+// writeXML writes v as an indented XML document with a declaration.
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
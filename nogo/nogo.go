@@ -0,0 +1,44 @@
+// Package nogo exposes nilguard to the nogo static analysis framework of
+// rules_go (Bazel).
+//
+// nogo builds a vet-like binary from go_library targets that export a
+// variable named Analyzer. Add this package to the nogo rule:
+//
+//	nogo(
+//	    name = "nogo",
+//	    deps = ["@com_github_hmetcalfe_nilguard//nogo"],
+//	    config = "nogo_config.json",
+//	    visibility = ["//visibility:public"],
+//	)
+//
+// and configure it in nogo_config.json under the analyzer name "nilguard".
+// nogo's exclude_files and only_files maps (file path regular expression to
+// reason) are applied by nogo itself; analyzer_flags set the Analyzer's own
+// flags, including the equivalent -exclude-files and -only-files regular
+// expressions, which skip excluded files before they are analyzed:
+//
+//	{
+//	  "nilguard": {
+//	    "exclude_files": {
+//	      "external/": "third-party code",
+//	      "_test\\.go$": "tests are exempt"
+//	    },
+//	    "analyzer_flags": {
+//	      "closure-guards": "true",
+//	      "exclude-files": "(^|/)legacy/"
+//	    }
+//	  }
+//	}
+//
+// File names are matched as nogo sees them: paths relative to the execution
+// root, such as "pkg/foo.go" or "external/some_repo/foo.go".
+//
+// Analyzer is created with analyzer.New, so its options live in its own flag
+// set rather than in package-level variables, and it does not share state
+// with other nilguard instances linked into the same binary.
+package nogo
+
+import "github.com/HMetcalfe/nilguard/internal/analyzer"
+
+// Analyzer is the nilguard Analyzer picked up by nogo.
+var Analyzer = analyzer.New(analyzer.Config{})
@@ -0,0 +1,40 @@
+package nogo
+
+import (
+	"testing"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// TestExcludeFiles sets -exclude-files through an Analyzer's flags, as nogo
+// does for analyzer_flags, and verifies that matching files are skipped.
+func TestExcludeFiles(t *testing.T) {
+	a := analyzer.New(analyzer.Config{})
+	if err := a.Flags.Set("exclude-files", "(^|/)legacy/"); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), a, "excluded", "excluded/legacy")
+}
+
+// TestOnlyFiles verifies that -only-files restricts analysis to matching files.
+func TestOnlyFiles(t *testing.T) {
+	a := analyzer.New(analyzer.Config{})
+	if err := a.Flags.Set("only-files", `/api\.go$`); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), a, "only")
+}
+
+// TestAnalyzerIsIndependent verifies that setting flags on the nogo Analyzer
+// does not configure the default nilguard Analyzer.
+func TestAnalyzerIsIndependent(t *testing.T) {
+	if err := Analyzer.Flags.Set("closure-guards", "true"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = Analyzer.Flags.Set("closure-guards", "false") })
+
+	if got := analyzer.Analyzer.Flags.Lookup("closure-guards").Value.String(); got != "false" {
+		t.Errorf("default Analyzer closure-guards = %s, want false", got)
+	}
+}
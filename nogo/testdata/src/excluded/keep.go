@@ -0,0 +1,13 @@
+// Package excluded exercises -exclude-files.
+package excluded
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// kept is analyzed: its file does not match the exclusion.
+func kept(p *S) {
+	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
@@ -0,0 +1,13 @@
+// Package legacy lives in a directory excluded by -exclude-files.
+package legacy
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// skipped is not analyzed: its file matches the exclusion.
+func skipped(p *S) {
+	_ = p.X
+}
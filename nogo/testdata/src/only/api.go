@@ -0,0 +1,13 @@
+// Package only exercises -only-files.
+package only
+
+// S is a sample struct mirroring the other test packages.
+type S struct {
+	// X is a dummy field used for selector access in tests.
+	X int
+}
+
+// checked is analyzed: its file matches -only-files.
+func checked(p *S) {
+	_ = p.X // want "pointer \"p\" is used in this function but never nil-checked"
+}
@@ -0,0 +1,6 @@
+package only
+
+// unchecked is not analyzed: its file does not match -only-files.
+func unchecked(p *S) {
+	_ = p.X
+}
@@ -0,0 +1,168 @@
+// Package nilguard is the public, embeddable API of the nilguard analyzer.
+//
+// Tools that compose their own analysis passes can use New to obtain a
+// configured *analysis.Analyzer, reuse the guard-recognition primitives that
+// nilguard itself is built on, or call Check to run nilguard over packages
+// and receive structured findings:
+//
+//	findings, err := nilguard.Check("./...")
+//	if err != nil {
+//	    return err
+//	}
+//	for _, f := range findings {
+//	    fmt.Printf("%s: %s (%s in %s)\n", f.Pos, f.Message, f.Pointer, f.Func)
+//	}
+//
+// The exported identifiers of this package are covered by the module's
+// compatibility promise; the packages under internal/ are not.
+package nilguard
+
+import (
+	"go/ast"
+	"go/types"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/HMetcalfe/nilguard/internal/driver"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Config holds the options of a nilguard Analyzer. The zero Config is the
+// default policy.
+type Config = analyzer.Config
+
+// Finding is a single nilguard diagnostic with resolved positions and the
+// enclosing function and pointer names.
+type Finding = driver.Finding
+
+// Related is a secondary location attached to a Finding.
+type Related = driver.Related
+
+// Fix is a suggested fix for a Finding: a set of edits to be applied
+// together.
+type Fix = driver.Fix
+
+// Edit replaces the text between two positions of a file.
+type Edit = driver.Edit
+
+// Suppression describes why a Finding is not reported.
+type Suppression = driver.Suppression
+
+// Kinds of Suppression, the values of Suppression.Kind.
+const (
+	SuppressedByDirective = driver.SuppressedByDirective
+	SuppressedByBaseline  = driver.SuppressedByBaseline
+)
+
+// Rule describes one kind of diagnostic reported by nilguard.
+type Rule = analyzer.Rule
+
+// Result is the analysis result of a nilguard Analyzer for one package, for
+// use by analyzers that require it.
+type Result = analyzer.Result
+
+// Rule identifiers, used as the Category of reported diagnostics.
+const (
+	RuleUnchecked         = analyzer.RuleUnchecked
+	RuleNilAssign         = analyzer.RuleNilAssign
+	RuleNearMiss          = analyzer.RuleNearMiss
+	RuleUnusedSuppression = analyzer.RuleUnusedSuppression
+	RuleSuppressionReason = analyzer.RuleSuppressionReason
+	RuleBadAnnotation     = analyzer.RuleBadAnnotation
+)
+
+// Profiles, the values of Config.Profile.
+const (
+	ProfileLenient  = analyzer.ProfileLenient
+	ProfileStandard = analyzer.ProfileStandard
+	ProfileStrict   = analyzer.ProfileStrict
+)
+
+// Report modes, the values of Config.Report.
+const (
+	ReportFirst   = analyzer.ReportFirst
+	ReportAll     = analyzer.ReportAll
+	ReportSummary = analyzer.ReportSummary
+)
+
+// Kinds of pointer use, the values of Finding.UseKind.
+const (
+	UseDereference = analyzer.UseDereference
+	UseField       = analyzer.UseField
+	UseMethod      = analyzer.UseMethod
+)
+
+// Severities, the values of Finding.Severity.
+const (
+	SeverityError   = analyzer.SeverityError
+	SeverityWarning = analyzer.SeverityWarning
+	SeverityNote    = analyzer.SeverityNote
+)
+
+// Analyzer is the default nilguard Analyzer, configured through its
+// command-line flags.
+var Analyzer = analyzer.Analyzer
+
+// New returns a nilguard Analyzer that applies cfg, independent of Analyzer
+// and its flags.
+func New(cfg Config) *analysis.Analyzer {
+	return analyzer.New(cfg)
+}
+
+// Rules returns every rule nilguard can report.
+func Rules() []Rule {
+	return append([]Rule(nil), analyzer.Rules...)
+}
+
+// Check loads the packages matching patterns (in go list syntax, relative to
+// the current directory, including tests) and runs nilguard over them with
+// the default Config. Findings are sorted by position. Errors loading or
+// type-checking the packages are returned as an error.
+func Check(patterns ...string) ([]Finding, error) {
+	return CheckConfig(Config{}, patterns...)
+}
+
+// CheckConfig is like Check but applies cfg. Test files are not loaded when
+// cfg.ExcludeTests is set.
+func CheckConfig(cfg Config, patterns ...string) ([]Finding, error) {
+	return driver.Run(New(cfg), patterns, driver.Config{Tests: !cfg.ExcludeTests})
+}
+
+// BaseIdentOf returns the identifier underlying expr, looking through
+// parentheses, or nil if expr is not a (parenthesized) identifier.
+func BaseIdentOf(expr ast.Expr) *ast.Ident {
+	return analyzer.BaseIdentOf(expr)
+}
+
+// IsPointerType reports whether t is tracked as a pointer: its underlying
+// type is a pointer, or it is a type parameter whose core type is a pointer.
+func IsPointerType(t types.Type) bool {
+	return analyzer.IsPointerType(t)
+}
+
+// CollectNilChecks returns the pointer identifiers compared against nil in e,
+// looking through && and || chains: those compared with != and those
+// compared with ==.
+func CollectNilChecks(info *types.Info, e ast.Expr) (neq, eql []*ast.Ident) {
+	return analyzer.CollectNilChecks(info, e)
+}
+
+// NonNilWhenTrue returns the pointer identifiers known to be non-nil whenever
+// e evaluates to true (p != nil, possibly joined with &&).
+func NonNilWhenTrue(info *types.Info, e ast.Expr) []*ast.Ident {
+	return analyzer.NonNilWhenTrue(info, e)
+}
+
+// NonNilWhenFalse returns the pointer identifiers known to be non-nil
+// whenever e evaluates to false (p == nil, possibly joined with ||).
+func NonNilWhenFalse(info *types.Info, e ast.Expr) []*ast.Ident {
+	return analyzer.NonNilWhenFalse(info, e)
+}
+
+// ExitsEarly reports whether b ends with a statement that leaves the
+// enclosing block, as nilguard decides whether an if p == nil body guards
+// the code after it: return, panic(...), break, continue, goto, or a call
+// that does not return, such as os.Exit(...), log.Fatal(...) or t.Fatal(...).
+// Calls other than panic are only recognized when info is not nil.
+func ExitsEarly(info *types.Info, b *ast.BlockStmt) bool {
+	return analyzer.ExitsEarly(info, b)
+}
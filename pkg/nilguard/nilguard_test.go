@@ -0,0 +1,105 @@
+package nilguard_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HMetcalfe/nilguard/pkg/nilguard"
+)
+
+// TestCheck runs Check over a throwaway module and verifies the structured
+// findings.
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/m\n\ngo 1.21\n")
+	write("m.go", `package m
+
+type S struct{ X int }
+
+func (s *S) Get() int { return s.X }
+
+func guarded(p *S) int {
+	if p == nil {
+		return 0
+	}
+	return p.X
+}
+`)
+	t.Chdir(dir)
+
+	findings, err := nilguard.Check("./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Rule != nilguard.RuleUnchecked || f.Package != "example.com/m" || f.Func != "(*S).Get" || f.Pointer != "s" {
+		t.Errorf("unexpected finding %+v", f)
+	}
+	if f.Pos.Line != 5 {
+		t.Errorf("finding at line %d, want 5", f.Pos.Line)
+	}
+}
+
+// TestRules verifies that every rule nilguard can report has an exported
+// identifier.
+func TestRules(t *testing.T) {
+	exported := map[string]bool{
+		nilguard.RuleUnchecked:         true,
+		nilguard.RuleNilAssign:         true,
+		nilguard.RuleNearMiss:          true,
+		nilguard.RuleUnusedSuppression: true,
+		nilguard.RuleSuppressionReason: true,
+		nilguard.RuleBadAnnotation:     true,
+	}
+	for _, r := range nilguard.Rules() {
+		if !exported[r.ID] {
+			t.Errorf("rule %q has no exported identifier", r.ID)
+		}
+	}
+}
+
+// TestExitsEarly verifies that ExitsEarly recognizes calls that do not
+// return, as the analyzer does, when given type information.
+func TestExitsEarly(t *testing.T) {
+	const src = `package m
+
+import "log"
+
+func f(p *int) {
+	if p == nil {
+		log.Fatal("no p")
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "m.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	if _, err := (&types.Config{Importer: importer.Default()}).Check("m", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+	body := f.Decls[1].(*ast.FuncDecl).Body.List[0].(*ast.IfStmt).Body
+	if !nilguard.ExitsEarly(info, body) {
+		t.Error("log.Fatal is not an exit")
+	}
+	if nilguard.ExitsEarly(nil, body) {
+		t.Error("log.Fatal is an exit without type information")
+	}
+}
@@ -0,0 +1,98 @@
+// Package golangci registers nilguard with golangci-lint's module plugin
+// system.
+//
+// Unlike the legacy -buildmode=plugin route in ../main.go, module plugins are
+// compiled into a custom golangci-lint binary by `golangci-lint custom`, so
+// they work on every platform and cannot drift from golangci-lint's own
+// dependency versions. Reference this package from .custom-gcl.yml:
+//
+//	version: v2.1.0
+//	plugins:
+//	  - module: github.com/HMetcalfe/nilguard
+//	    import: github.com/HMetcalfe/nilguard/plugin/golangci
+//	    version: latest
+//
+// and enable it in .golangci.yml (golangci-lint v2), passing options under
+// settings. Every option is optional; the values below are examples:
+//
+//	version: "2"
+//	linters:
+//	  enable:
+//	    - nilguard
+//	  settings:
+//	    custom:
+//	      nilguard:
+//	        type: module
+//	        description: Flags pointer uses without a nil check
+//	        settings:
+//	          exclude-tests: true
+//	          closure-guards: true
+//	          only-files: "^internal/"
+//	          exclude-files: "(^|/)legacy/"
+//	          require-reason: true
+//	          include-generated: false
+//	          report: summary
+//	          profile: standard
+package golangci
+
+import (
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+)
+
+func init() {
+	register.Plugin("nilguard", New)
+}
+
+// Settings mirrors analyzer.Config using the kebab-case keys accepted under
+// linters.settings.custom.nilguard.settings. Unknown keys are rejected.
+type Settings struct {
+	ExcludeTests     bool   `json:"exclude-tests"`
+	ClosureGuards    bool   `json:"closure-guards"`
+	OnlyFiles        string `json:"only-files"`
+	ExcludeFiles     string `json:"exclude-files"`
+	RequireReason    bool   `json:"require-reason"`
+	IncludeGenerated bool   `json:"include-generated"`
+	Report           string `json:"report"`
+	Profile          string `json:"profile"`
+}
+
+// config converts s to the analyzer configuration.
+func (s Settings) config() analyzer.Config {
+	return analyzer.Config{
+		ExcludeTests:     s.ExcludeTests,
+		ClosureGuards:    s.ClosureGuards,
+		OnlyFiles:        s.OnlyFiles,
+		ExcludeFiles:     s.ExcludeFiles,
+		RequireReason:    s.RequireReason,
+		IncludeGenerated: s.IncludeGenerated,
+		Report:           s.Report,
+		Profile:          s.Profile,
+	}
+}
+
+// Plugin implements register.LinterPlugin for nilguard.
+type Plugin struct {
+	settings Settings
+}
+
+// New decodes the raw golangci-lint settings and returns the plugin. It is
+// the register.NewPlugin constructor registered under the name "nilguard".
+func New(settings any) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[Settings](settings)
+	if err != nil {
+		return nil, err
+	}
+	return &Plugin{settings: s}, nil
+}
+
+// BuildAnalyzers returns a nilguard Analyzer configured from the settings.
+func (p *Plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return []*analysis.Analyzer{analyzer.New(p.settings.config())}, nil
+}
+
+// GetLoadMode reports that nilguard needs full type information.
+func (p *Plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
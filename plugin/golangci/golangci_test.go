@@ -0,0 +1,65 @@
+package golangci
+
+import (
+	"testing"
+
+	"github.com/HMetcalfe/nilguard/internal/analyzer"
+	"github.com/golangci/plugin-module-register/register"
+)
+
+// TestSettings verifies that settings from .golangci.yml are decoded into the
+// analyzer configuration and that unknown keys are rejected.
+func TestSettings(t *testing.T) {
+	newPlugin, err := register.GetPlugin("nilguard")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lp, err := newPlugin(map[string]any{"exclude-tests": true, "closure-guards": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := lp.(*Plugin)
+	if cfg := p.settings.config(); !cfg.ExcludeTests || !cfg.ClosureGuards {
+		t.Errorf("config = %+v, want both options set", cfg)
+	}
+	if lp.GetLoadMode() != register.LoadModeTypesInfo {
+		t.Errorf("load mode = %q", lp.GetLoadMode())
+	}
+	as, err := lp.BuildAnalyzers()
+	if err != nil || len(as) != 1 || as[0].Name != "nilguard" {
+		t.Errorf("BuildAnalyzers() = %v, %v", as, err)
+	}
+
+	// Every setting documented in the package comment.
+	lp, err = newPlugin(map[string]any{
+		"exclude-tests":     true,
+		"closure-guards":    true,
+		"only-files":        "^internal/",
+		"exclude-files":     "(^|/)legacy/",
+		"require-reason":    true,
+		"include-generated": true,
+		"report":            "summary",
+		"profile":           "standard",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := analyzer.Config{
+		ExcludeTests:     true,
+		ClosureGuards:    true,
+		OnlyFiles:        "^internal/",
+		ExcludeFiles:     "(^|/)legacy/",
+		RequireReason:    true,
+		IncludeGenerated: true,
+		Report:           analyzer.ReportSummary,
+		Profile:          analyzer.ProfileStandard,
+	}
+	if cfg := lp.(*Plugin).settings.config(); cfg != want {
+		t.Errorf("config = %+v, want %+v", cfg, want)
+	}
+
+	if _, err := newPlugin(map[string]any{"no-such-option": 1}); err == nil {
+		t.Error("unknown setting accepted")
+	}
+}